@@ -0,0 +1,115 @@
+// Package audit mencatat tindakan sensitif seperti login, perubahan role, dan perubahan status
+// checkout maupun pembayaran ke koleksi audit_events yang hanya ditambah. Handler dapat memakai
+// Middleware di rute, Record di dalam handler, atau Log untuk proses di luar request HTTP.
+package audit
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"be-stepup/package/reqctx"
+	"be-stepup/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"time"
+)
+
+// retention adalah lama event disimpan sebelum dihapus oleh TTL index
+var retention = config.ConfigDuration("AUDIT_RETENTION", 365*24*time.Hour)
+
+// writeTimeout membatasi penyimpanan event yang tidak lagi terikat deadline request
+const writeTimeout = 5 * time.Second
+
+const (
+	anonymousActor = "anonymous"
+	detailsKey     = "auditDetails"
+)
+
+// Log menyimpan event. Pelaku dan request ID diambil dari ctx jika belum diisi, dan outcome
+// kosong dianggap berhasil. Kegagalan hanya dicatat di log agar tidak menggagalkan tindakan
+// yang sudah terjadi.
+func Log(ctx context.Context, event models.AuditEvent) {
+	if event.Actor == "" {
+		event.Actor = reqctx.UserID(ctx)
+	}
+	if event.Actor == "" {
+		event.Actor = anonymousActor
+	}
+	if event.RequestID == "" {
+		event.RequestID = reqctx.RequestID(ctx)
+	}
+	if event.Outcome == "" {
+		event.Outcome = models.AuditSuccess
+	}
+	event.CreatedAt = time.Now()
+	event.ExpiresAt = event.CreatedAt.Add(retention)
+
+	// Event tetap disimpan walaupun request sudah timeout atau dibatalkan klien
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	if err := repository.InsertAuditEvent(ctx, &event); err != nil {
+		log.Printf("Error recording audit event %s on %s %s: %v\n", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+// Record menyimpan event dari handler dengan IP, user agent, dan request ID dari request.
+// Keterangan yang ditambahkan lewat Detail ikut disimpan.
+func Record(c *fiber.Ctx, event models.AuditEvent) {
+	event.IP = c.IP()
+	event.UserAgent = c.Get(fiber.HeaderUserAgent)
+	if details, ok := c.Locals(detailsKey).(map[string]interface{}); ok {
+		if event.Details == nil {
+			event.Details = map[string]interface{}{}
+		}
+		for key, value := range details {
+			if _, exists := event.Details[key]; !exists {
+				event.Details[key] = value
+			}
+		}
+	}
+	Log(c.UserContext(), event)
+}
+
+// Detail menambahkan keterangan, misalnya status sebelum dan sesudah, ke event request ini
+func Detail(c *fiber.Ctx, key string, value interface{}) {
+	details, ok := c.Locals(detailsKey).(map[string]interface{})
+	if !ok {
+		details = map[string]interface{}{}
+		c.Locals(detailsKey, details)
+	}
+	details[key] = value
+}
+
+// Middleware mencatat action terhadap target dengan ID dari parameter rute targetParam setelah
+// handler selesai. Response dengan status di bawah 400 dicatat berhasil, selain itu gagal,
+// sehingga percobaan yang ditolak handler juga tercatat. Pasang setelah middleware autentikasi
+// agar request anonim ditolak sebelum sempat menulis ke audit_events.
+func Middleware(action, targetType, targetParam string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		targetID := c.Params(targetParam)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Error yang dikembalikan handler baru ditulis oleh error handler setelah middleware ini
+			status = fiber.StatusInternalServerError
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			}
+		}
+		outcome := models.AuditSuccess
+		if status >= fiber.StatusBadRequest {
+			outcome = models.AuditFailure
+		}
+		Detail(c, "status_code", status)
+
+		Record(c, models.AuditEvent{
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Outcome:    outcome,
+		})
+		return err
+	}
+}
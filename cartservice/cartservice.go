@@ -0,0 +1,276 @@
+// Package cartservice berisi aturan jumlah item keranjang yang dipakai oleh semua
+// handler keranjang: tambah, ubah jumlah, hapus, validasi ulang, dan penggabungan
+// keranjang tamu. Paket ini tidak mengakses database; pemanggil memuat produk dan
+// keranjang lalu menyimpan hasilnya.
+package cartservice
+
+import (
+	"be-stepup/models"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidQuantity   = errors.New("jumlah harus lebih dari 0")
+	ErrInsufficientStock = errors.New("stok produk tidak cukup")
+	ErrItemNotFound      = errors.New("item tidak ditemukan di keranjang")
+)
+
+// MaxQuantityError dikembalikan jika jumlah melebihi batas maksimum pembelian per produk
+type MaxQuantityError struct {
+	ProductName string
+	Max         int
+}
+
+func (e *MaxQuantityError) Error() string {
+	return fmt.Sprintf("maksimal pembelian %s adalah %d per pesanan", e.ProductName, e.Max)
+}
+
+// Jenis peringatan item keranjang
+const (
+	WarningOutOfStock      = "out_of_stock"
+	WarningQuantityReduced = "quantity_reduced"
+	WarningPriceChanged    = "price_changed"
+	WarningRemoved         = "removed"
+)
+
+// Warning menjelaskan perubahan pada satu item keranjang yang perlu diketahui user
+type Warning struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Type        string `json:"type"`
+	Message     string `json:"message"`
+}
+
+// Rules adalah aturan jumlah yang bisa dikonfigurasi
+type Rules struct {
+	// DefaultMaxPerProduct berlaku untuk produk yang tidak mengisi max_order_quantity; 0 berarti tanpa batas
+	DefaultMaxPerProduct int
+}
+
+// Service menerapkan Rules pada item keranjang
+type Service struct {
+	Rules Rules
+}
+
+// New membuat Service dengan aturan tertentu
+func New(rules Rules) *Service {
+	return &Service{Rules: rules}
+}
+
+// MaxOrderQuantity mengembalikan batas pembelian produk tanpa memperhitungkan stok; 0 berarti tanpa batas
+func (s *Service) MaxOrderQuantity(product models.Product) int {
+	if product.MaxOrderQuantity > 0 {
+		return product.MaxOrderQuantity
+	}
+	return s.Rules.DefaultMaxPerProduct
+}
+
+// CheckQuantity memastikan jumlah total satu produk tidak melebihi stok dan batas pembelian
+func (s *Service) CheckQuantity(product models.Product, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if max := s.MaxOrderQuantity(product); max > 0 && quantity > max {
+		return &MaxQuantityError{ProductName: product.Name, Max: max}
+	}
+	if quantity > product.Stock {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// Add menambahkan quantity produk ke keranjang. Batas stok dan pembelian diperiksa
+// terhadap jumlah akhir di keranjang, bukan hanya tambahannya.
+func (s *Service) Add(items []models.CartItem, product models.Product, quantity int) ([]models.CartItem, error) {
+	if quantity <= 0 {
+		return items, ErrInvalidQuantity
+	}
+
+	index := find(items, product.ProductID)
+	total := quantity
+	if index >= 0 {
+		total += items[index].Quantity
+	}
+	if err := s.CheckQuantity(product, total); err != nil {
+		return items, err
+	}
+
+	updated := append([]models.CartItem(nil), items...)
+	if index >= 0 {
+		updated[index] = snapshot(product, total)
+	} else {
+		updated = append(updated, snapshot(product, total))
+	}
+	return updated, nil
+}
+
+// SetQuantity mengganti jumlah item; jumlah 0 atau kurang menghapus item dari keranjang
+func (s *Service) SetQuantity(items []models.CartItem, product models.Product, quantity int) ([]models.CartItem, error) {
+	index := find(items, product.ProductID)
+	if index < 0 {
+		return items, ErrItemNotFound
+	}
+	if quantity <= 0 {
+		return s.Remove(items, product.ProductID)
+	}
+	if err := s.CheckQuantity(product, quantity); err != nil {
+		return items, err
+	}
+
+	updated := append([]models.CartItem(nil), items...)
+	updated[index] = snapshot(product, quantity)
+	return updated, nil
+}
+
+// Remove menghapus item produk dari keranjang
+func (s *Service) Remove(items []models.CartItem, productID string) ([]models.CartItem, error) {
+	index := find(items, productID)
+	if index < 0 {
+		return items, ErrItemNotFound
+	}
+	updated := make([]models.CartItem, 0, len(items)-1)
+	updated = append(updated, items[:index]...)
+	return append(updated, items[index+1:]...), nil
+}
+
+// Reconcile mencocokkan item keranjang dengan data produk terbaru. Produk yang sudah
+// dihapus dikeluarkan, jumlah dibatasi stok dan batas pembelian, dan harga serta nama
+// diperbarui. Item yang stoknya habis tetap disimpan agar user bisa memutuskan sendiri.
+// changed bernilai true jika keranjang perlu disimpan ulang.
+func (s *Service) Reconcile(items []models.CartItem, products map[string]models.Product) (reconciled []models.CartItem, warnings []Warning, changed bool) {
+	reconciled = make([]models.CartItem, 0, len(items))
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			warnings = append(warnings, Warning{
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				Type:        WarningRemoved,
+				Message:     "Produk sudah tidak tersedia dan dihapus dari keranjang",
+			})
+			changed = true
+			continue
+		}
+
+		if product.Stock <= 0 {
+			warnings = append(warnings, Warning{
+				ProductID:   product.ProductID,
+				ProductName: product.Name,
+				Type:        WarningOutOfStock,
+				Message:     "Stok produk habis",
+			})
+		} else if limit := s.limit(product); item.Quantity > limit {
+			warnings = append(warnings, Warning{
+				ProductID:   product.ProductID,
+				ProductName: product.Name,
+				Type:        WarningQuantityReduced,
+				Message:     fmt.Sprintf("Jumlah disesuaikan dari %d menjadi %d", item.Quantity, limit),
+			})
+			item.Quantity = limit
+			changed = true
+		}
+
+		if !item.Price.Equal(product.Price) {
+			warnings = append(warnings, Warning{
+				ProductID:   product.ProductID,
+				ProductName: product.Name,
+				Type:        WarningPriceChanged,
+				Message:     "Harga berubah dari " + item.Price.Format() + " menjadi " + product.Price.Format(),
+			})
+			changed = true
+		}
+
+		refreshed := snapshot(product, item.Quantity)
+		if refreshed != item {
+			changed = true
+		}
+		reconciled = append(reconciled, refreshed)
+	}
+	return reconciled, warnings, changed
+}
+
+// Merge menggabungkan item keranjang tamu ke keranjang user. Jumlah produk yang sama
+// dijumlahkan lalu dibatasi stok dan batas pembelian; produk yang sudah tidak ada dilewati.
+func (s *Service) Merge(userItems, guestItems []models.CartItem, products map[string]models.Product) ([]models.CartItem, []Warning) {
+	items := append([]models.CartItem(nil), userItems...)
+	var warnings []Warning
+	for _, guestItem := range guestItems {
+		product, ok := products[guestItem.ProductID]
+		if !ok {
+			warnings = append(warnings, Warning{
+				ProductID:   guestItem.ProductID,
+				ProductName: guestItem.ProductName,
+				Type:        WarningRemoved,
+				Message:     "Produk sudah tidak tersedia",
+			})
+			continue
+		}
+
+		index := find(items, product.ProductID)
+		existing := 0
+		if index >= 0 {
+			existing = items[index].Quantity
+		}
+
+		quantity := existing + guestItem.Quantity
+		if limit := s.limit(product); quantity > limit {
+			quantity = limit
+			if quantity <= existing {
+				warnings = append(warnings, Warning{
+					ProductID:   product.ProductID,
+					ProductName: product.Name,
+					Type:        WarningOutOfStock,
+					Message:     "Stok tidak cukup untuk menambahkan item dari keranjang tamu",
+				})
+				continue
+			}
+			warnings = append(warnings, Warning{
+				ProductID:   product.ProductID,
+				ProductName: product.Name,
+				Type:        WarningQuantityReduced,
+				Message:     fmt.Sprintf("Jumlah disesuaikan menjadi %d", quantity),
+			})
+		}
+
+		if index >= 0 {
+			items[index] = snapshot(product, quantity)
+		} else {
+			items = append(items, snapshot(product, quantity))
+		}
+	}
+	return items, warnings
+}
+
+// limit adalah jumlah terbesar yang boleh ada di keranjang untuk produk tersebut
+func (s *Service) limit(product models.Product) int {
+	limit := product.Stock
+	if max := s.MaxOrderQuantity(product); max > 0 && max < limit {
+		limit = max
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+func find(items []models.CartItem, productID string) int {
+	for i, item := range items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+// snapshot menyalin data produk terbaru ke item keranjang; harga selalu dari produk
+func snapshot(product models.Product, quantity int) models.CartItem {
+	return models.CartItem{
+		ProductID:   product.ProductID,
+		ProductCode: product.Code,
+		ProductName: product.Name,
+		Quantity:    quantity,
+		Price:       product.Price,
+		ImageURL:    product.ImageURL,
+	}
+}
@@ -0,0 +1,352 @@
+package cartservice
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func product(id string, stock, maxOrder int) models.Product {
+	return models.Product{
+		ProductID:        id,
+		Code:             "SKU-" + id,
+		Name:             "Sepatu " + id,
+		Price:            money.IDR(500000),
+		Stock:            stock,
+		MaxOrderQuantity: maxOrder,
+	}
+}
+
+func item(id string, quantity int) models.CartItem {
+	return snapshot(product(id, 0, 0), quantity)
+}
+
+func quantities(items []models.CartItem) map[string]int {
+	out := map[string]int{}
+	for _, item := range items {
+		out[item.ProductID] = item.Quantity
+	}
+	return out
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		items    []models.CartItem
+		product  models.Product
+		quantity int
+		want     map[string]int
+		wantErr  error
+		wantMax  int
+	}{
+		{
+			name:     "new item within stock",
+			product:  product("A", 5, 0),
+			quantity: 2,
+			want:     map[string]int{"A": 2},
+		},
+		{
+			name:     "accumulates existing quantity",
+			items:    []models.CartItem{item("A", 2)},
+			product:  product("A", 5, 0),
+			quantity: 3,
+			want:     map[string]int{"A": 5},
+		},
+		{
+			name:     "accumulated quantity exceeds stock",
+			items:    []models.CartItem{item("A", 4)},
+			product:  product("A", 5, 0),
+			quantity: 2,
+			wantErr:  ErrInsufficientStock,
+		},
+		{
+			name:     "zero quantity rejected",
+			product:  product("A", 5, 0),
+			quantity: 0,
+			wantErr:  ErrInvalidQuantity,
+		},
+		{
+			name:     "negative quantity rejected",
+			items:    []models.CartItem{item("A", 3)},
+			product:  product("A", 5, 0),
+			quantity: -1,
+			wantErr:  ErrInvalidQuantity,
+		},
+		{
+			name:     "product max order quantity",
+			items:    []models.CartItem{item("A", 1)},
+			product:  product("A", 10, 2),
+			quantity: 2,
+			wantMax:  2,
+		},
+		{
+			name:     "default max applies when product has none",
+			rules:    Rules{DefaultMaxPerProduct: 3},
+			product:  product("A", 10, 0),
+			quantity: 4,
+			wantMax:  3,
+		},
+		{
+			name:     "product max overrides default",
+			rules:    Rules{DefaultMaxPerProduct: 3},
+			product:  product("A", 10, 6),
+			quantity: 5,
+			want:     map[string]int{"A": 5},
+		},
+		{
+			name:     "other items untouched",
+			items:    []models.CartItem{item("B", 1)},
+			product:  product("A", 5, 0),
+			quantity: 1,
+			want:     map[string]int{"A": 1, "B": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.rules).Add(tt.items, tt.product, tt.quantity)
+			checkErr(t, err, tt.wantErr, tt.wantMax)
+			if tt.wantErr == nil && tt.wantMax == 0 && !reflect.DeepEqual(quantities(got), tt.want) {
+				t.Errorf("quantities = %v, want %v", quantities(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		items    []models.CartItem
+		product  models.Product
+		quantity int
+		want     map[string]int
+		wantErr  error
+		wantMax  int
+	}{
+		{
+			name:     "replaces quantity",
+			items:    []models.CartItem{item("A", 1)},
+			product:  product("A", 5, 0),
+			quantity: 4,
+			want:     map[string]int{"A": 4},
+		},
+		{
+			name:     "zero removes item",
+			items:    []models.CartItem{item("A", 1), item("B", 2)},
+			product:  product("A", 5, 0),
+			quantity: 0,
+			want:     map[string]int{"B": 2},
+		},
+		{
+			name:     "negative removes item",
+			items:    []models.CartItem{item("A", 1)},
+			product:  product("A", 5, 0),
+			quantity: -3,
+			want:     map[string]int{},
+		},
+		{
+			name:     "exceeds stock",
+			items:    []models.CartItem{item("A", 1)},
+			product:  product("A", 5, 0),
+			quantity: 6,
+			wantErr:  ErrInsufficientStock,
+		},
+		{
+			name:     "exceeds max order quantity",
+			items:    []models.CartItem{item("A", 1)},
+			product:  product("A", 50, 3),
+			quantity: 4,
+			wantMax:  3,
+		},
+		{
+			name:     "item not in cart",
+			items:    []models.CartItem{item("B", 1)},
+			product:  product("A", 5, 0),
+			quantity: 1,
+			wantErr:  ErrItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.rules).SetQuantity(tt.items, tt.product, tt.quantity)
+			checkErr(t, err, tt.wantErr, tt.wantMax)
+			if tt.wantErr == nil && tt.wantMax == 0 && !reflect.DeepEqual(quantities(got), tt.want) {
+				t.Errorf("quantities = %v, want %v", quantities(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	changedPrice := product("A", 5, 0)
+	changedPrice.Price = money.IDR(450000)
+
+	tests := []struct {
+		name         string
+		rules        Rules
+		items        []models.CartItem
+		products     map[string]models.Product
+		want         map[string]int
+		wantWarnings []string
+		wantChanged  bool
+	}{
+		{
+			name:     "unchanged",
+			items:    []models.CartItem{item("A", 2)},
+			products: map[string]models.Product{"A": product("A", 5, 0)},
+			want:     map[string]int{"A": 2},
+		},
+		{
+			name:         "deleted product removed",
+			items:        []models.CartItem{item("A", 2), item("B", 1)},
+			products:     map[string]models.Product{"B": product("B", 5, 0)},
+			want:         map[string]int{"B": 1},
+			wantWarnings: []string{WarningRemoved},
+			wantChanged:  true,
+		},
+		{
+			name:         "out of stock kept",
+			items:        []models.CartItem{item("A", 2)},
+			products:     map[string]models.Product{"A": product("A", 0, 0)},
+			want:         map[string]int{"A": 2},
+			wantWarnings: []string{WarningOutOfStock},
+		},
+		{
+			name:         "quantity reduced to stock",
+			items:        []models.CartItem{item("A", 4)},
+			products:     map[string]models.Product{"A": product("A", 3, 0)},
+			want:         map[string]int{"A": 3},
+			wantWarnings: []string{WarningQuantityReduced},
+			wantChanged:  true,
+		},
+		{
+			name:         "quantity reduced to max order quantity",
+			rules:        Rules{DefaultMaxPerProduct: 2},
+			items:        []models.CartItem{item("A", 4)},
+			products:     map[string]models.Product{"A": product("A", 10, 0)},
+			want:         map[string]int{"A": 2},
+			wantWarnings: []string{WarningQuantityReduced},
+			wantChanged:  true,
+		},
+		{
+			name:         "price changed",
+			items:        []models.CartItem{item("A", 1)},
+			products:     map[string]models.Product{"A": changedPrice},
+			want:         map[string]int{"A": 1},
+			wantWarnings: []string{WarningPriceChanged},
+			wantChanged:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, changed := New(tt.rules).Reconcile(tt.items, tt.products)
+			if !reflect.DeepEqual(quantities(got), tt.want) {
+				t.Errorf("quantities = %v, want %v", quantities(got), tt.want)
+			}
+			if !reflect.DeepEqual(warningTypes(warnings), tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", warningTypes(warnings), tt.wantWarnings)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        Rules
+		userItems    []models.CartItem
+		guestItems   []models.CartItem
+		products     map[string]models.Product
+		want         map[string]int
+		wantWarnings []string
+	}{
+		{
+			name:       "guest items added to empty cart",
+			guestItems: []models.CartItem{item("A", 2)},
+			products:   map[string]models.Product{"A": product("A", 5, 0)},
+			want:       map[string]int{"A": 2},
+		},
+		{
+			name:       "same product summed",
+			userItems:  []models.CartItem{item("A", 1)},
+			guestItems: []models.CartItem{item("A", 2)},
+			products:   map[string]models.Product{"A": product("A", 5, 0)},
+			want:       map[string]int{"A": 3},
+		},
+		{
+			name:         "sum capped by stock",
+			userItems:    []models.CartItem{item("A", 3)},
+			guestItems:   []models.CartItem{item("A", 3)},
+			products:     map[string]models.Product{"A": product("A", 4, 0)},
+			want:         map[string]int{"A": 4},
+			wantWarnings: []string{WarningQuantityReduced},
+		},
+		{
+			name:         "no room left keeps user quantity",
+			userItems:    []models.CartItem{item("A", 4)},
+			guestItems:   []models.CartItem{item("A", 1)},
+			products:     map[string]models.Product{"A": product("A", 4, 0)},
+			want:         map[string]int{"A": 4},
+			wantWarnings: []string{WarningOutOfStock},
+		},
+		{
+			name:         "deleted product skipped",
+			guestItems:   []models.CartItem{item("A", 1), item("B", 1)},
+			products:     map[string]models.Product{"B": product("B", 5, 0)},
+			want:         map[string]int{"B": 1},
+			wantWarnings: []string{WarningRemoved},
+		},
+		{
+			name:         "sum capped by max order quantity",
+			rules:        Rules{DefaultMaxPerProduct: 2},
+			userItems:    []models.CartItem{item("A", 1)},
+			guestItems:   []models.CartItem{item("A", 2)},
+			products:     map[string]models.Product{"A": product("A", 10, 0)},
+			want:         map[string]int{"A": 2},
+			wantWarnings: []string{WarningQuantityReduced},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := New(tt.rules).Merge(tt.userItems, tt.guestItems, tt.products)
+			if !reflect.DeepEqual(quantities(got), tt.want) {
+				t.Errorf("quantities = %v, want %v", quantities(got), tt.want)
+			}
+			if !reflect.DeepEqual(warningTypes(warnings), tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", warningTypes(warnings), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func checkErr(t *testing.T, err, wantErr error, wantMax int) {
+	t.Helper()
+	if wantMax > 0 {
+		var maxErr *MaxQuantityError
+		if !errors.As(err, &maxErr) || maxErr.Max != wantMax {
+			t.Fatalf("err = %v, want MaxQuantityError with max %d", err, wantMax)
+		}
+		return
+	}
+	if err != wantErr {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+}
+
+func warningTypes(warnings []Warning) []string {
+	var types []string
+	for _, warning := range warnings {
+		types = append(types, warning.Type)
+	}
+	return types
+}
@@ -0,0 +1,21 @@
+package cartservice
+
+import (
+	"be-stepup/config"
+	"log"
+	"strconv"
+)
+
+// NewFromConfig membuat Service dengan batas bawaan dari CART_MAX_QUANTITY_PER_PRODUCT (0 atau kosong berarti tanpa batas)
+func NewFromConfig() *Service {
+	var rules Rules
+	if value := config.Config("CART_MAX_QUANTITY_PER_PRODUCT"); value != "" {
+		max, err := strconv.Atoi(value)
+		if err != nil || max < 0 {
+			log.Printf("Invalid CART_MAX_QUANTITY_PER_PRODUCT %q, using no limit\n", value)
+		} else {
+			rules.DefaultMaxPerProduct = max
+		}
+	}
+	return New(rules)
+}
@@ -0,0 +1,182 @@
+// Package catalog membaca dan menulis katalog produk dalam format tabel (CSV atau XLSX)
+// untuk impor dan ekspor massal. Paket ini tidak mengakses database; penyimpanan produk
+// dan pemeriksaan file gambar dilakukan oleh pemanggil.
+package catalog
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Currency adalah mata uang harga pada file katalog
+const Currency = "IDR"
+
+// Columns adalah kolom file katalog dengan urutan yang dipakai saat ekspor.
+// Saat impor urutan kolom bebas, tetapi semua kolom wajib ada di header.
+var Columns = []string{"code", "name", "brand", "category", "color", "price", "stock", "image"}
+
+// ErrEmptyFile dikembalikan jika file tidak berisi header
+var ErrEmptyFile = errors.New("file katalog kosong")
+
+// Row adalah satu produk yang sudah lolos validasi
+type Row struct {
+	Line     int // Nomor baris di file; header adalah baris 1
+	Code     string
+	Name     string
+	Brand    string
+	Category string
+	Color    string
+	Price    money.Money
+	Stock    int
+	Image    string // Nama file gambar di folder uploads
+}
+
+// Product mengubah baris menjadi produk; ProductID dan ImageURL diisi pemanggil
+func (r Row) Product() models.Product {
+	return models.Product{
+		Code:     r.Code,
+		Name:     r.Name,
+		Brand:    r.Brand,
+		Category: r.Category,
+		Color:    r.Color,
+		Price:    r.Price,
+		Stock:    r.Stock,
+	}
+}
+
+// ParseRecords memvalidasi isi file katalog. Baris kosong dilewati; baris yang tidak valid
+// dilaporkan per nomor baris tanpa menghentikan validasi baris lain. Error hanya dikembalikan
+// jika header tidak bisa dipakai.
+func ParseRecords(records [][]string) ([]Row, []models.ImportRowError, error) {
+	if len(records) == 0 {
+		return nil, nil, ErrEmptyFile
+	}
+
+	index := make(map[string]int, len(Columns))
+	for i, name := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, column := range Columns {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("kolom wajib tidak ditemukan: %s", strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	var rowErrors []models.ImportRowError
+	seen := map[string]int{}
+	for i, record := range records[1:] {
+		line := i + 2
+		get := func(column string) string {
+			if idx := index[column]; idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		row, problems := parseRow(line, get)
+		if first, ok := seen[strings.ToLower(row.Code)]; ok && row.Code != "" {
+			problems = append(problems, fmt.Sprintf("kode %s sudah dipakai di baris %d", row.Code, first))
+		} else if row.Code != "" {
+			seen[strings.ToLower(row.Code)] = line
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Code: row.Code, Errors: problems})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+func parseRow(line int, get func(string) string) (Row, []string) {
+	row := Row{
+		Line:     line,
+		Code:     get("code"),
+		Name:     get("name"),
+		Brand:    get("brand"),
+		Category: get("category"),
+		Color:    get("color"),
+		Image:    get("image"),
+	}
+
+	var problems []string
+	if row.Code == "" {
+		problems = append(problems, "code wajib diisi")
+	} else if len(row.Code) > 50 || strings.ContainsAny(row.Code, " \t") {
+		problems = append(problems, "code maksimal 50 karakter tanpa spasi")
+	}
+	if row.Name == "" {
+		problems = append(problems, "name wajib diisi")
+	} else if len(row.Name) > 200 {
+		problems = append(problems, "name maksimal 200 karakter")
+	}
+
+	price, err := money.Parse(get("price"), Currency)
+	if err != nil || !price.IsPositive() {
+		problems = append(problems, "price harus angka lebih dari nol")
+	}
+	row.Price = price
+
+	stock, err := strconv.Atoi(get("stock"))
+	if err != nil || stock < 0 {
+		problems = append(problems, "stock harus bilangan bulat tidak negatif")
+	}
+	row.Stock = stock
+
+	if row.Image != "" {
+		ext := strings.ToLower(filepath.Ext(row.Image))
+		if row.Image != filepath.Base(row.Image) || strings.ContainsAny(row.Image, `/\`) {
+			problems = append(problems, "image harus berupa nama file tanpa folder")
+		} else if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+			problems = append(problems, "image harus berupa file PNG, JPG, atau JPEG")
+		}
+	}
+	return row, problems
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ProductRecords mengubah produk menjadi isi file katalog lengkap dengan header
+func ProductRecords(products []models.Product) [][]string {
+	records := make([][]string, 0, len(products)+1)
+	records = append(records, Columns)
+	for _, product := range products {
+		image := ""
+		if product.ImageURL != "" {
+			image = path.Base(product.ImageURL)
+		}
+		records = append(records, []string{
+			product.Code,
+			product.Name,
+			product.Brand,
+			product.Category,
+			product.Color,
+			product.Price.DecimalString(),
+			strconv.Itoa(product.Stock),
+			image,
+		})
+	}
+	return records
+}
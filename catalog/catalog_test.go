@@ -0,0 +1,219 @@
+package catalog
+
+import (
+	"archive/zip"
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var header = []string{"code", "name", "brand", "category", "color", "price", "stock", "image"}
+
+func TestParseRecordsMissingColumns(t *testing.T) {
+	_, _, err := ParseRecords([][]string{{"code", "name", "brand", "category", "color"}})
+	if err == nil || !strings.Contains(err.Error(), "price, stock, image") {
+		t.Fatalf("err = %v, want missing price, stock, image", err)
+	}
+}
+
+func TestParseRecordsEmpty(t *testing.T) {
+	if _, _, err := ParseRecords(nil); err != ErrEmptyFile {
+		t.Fatalf("err = %v, want ErrEmptyFile", err)
+	}
+}
+
+func TestParseRecordsValidRow(t *testing.T) {
+	records := [][]string{
+		// Urutan kolom bebas dan nama kolom tidak peka huruf besar
+		{"Name", "CODE", "brand", "category", "color", "price", "stock", "image"},
+		{"Air Max", "SKU-1", "Nike", "Running", "Black", "1250000.50", "4", "air.png"},
+		{"", "", "", "", "", "", "", ""},
+	}
+	rows, rowErrors, err := ParseRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrors) != 0 {
+		t.Fatalf("rowErrors = %+v, want none", rowErrors)
+	}
+	want := []Row{{
+		Line:     2,
+		Code:     "SKU-1",
+		Name:     "Air Max",
+		Brand:    "Nike",
+		Category: "Running",
+		Color:    "Black",
+		Price:    money.New(125000050, "IDR"),
+		Stock:    4,
+		Image:    "air.png",
+	}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %+v, want %+v", rows, want)
+	}
+}
+
+func TestParseRecordsRowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		record []string
+		want   string
+	}{
+		{"missing code", []string{"", "Shoe", "", "", "", "100", "1", ""}, "code wajib diisi"},
+		{"code with space", []string{"SKU 1", "Shoe", "", "", "", "100", "1", ""}, "code maksimal 50 karakter tanpa spasi"},
+		{"missing name", []string{"SKU-1", "", "", "", "", "100", "1", ""}, "name wajib diisi"},
+		{"price not a number", []string{"SKU-1", "Shoe", "", "", "", "abc", "1", ""}, "price harus angka lebih dari nol"},
+		{"price zero", []string{"SKU-1", "Shoe", "", "", "", "0", "1", ""}, "price harus angka lebih dari nol"},
+		{"negative stock", []string{"SKU-1", "Shoe", "", "", "", "100", "-1", ""}, "stock harus bilangan bulat tidak negatif"},
+		{"fractional stock", []string{"SKU-1", "Shoe", "", "", "", "100", "1.5", ""}, "stock harus bilangan bulat tidak negatif"},
+		{"image with folder", []string{"SKU-1", "Shoe", "", "", "", "100", "1", "../secret.png"}, "image harus berupa nama file tanpa folder"},
+		{"image wrong type", []string{"SKU-1", "Shoe", "", "", "", "100", "1", "shoe.gif"}, "image harus berupa file PNG, JPG, atau JPEG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrors, err := ParseRecords([][]string{header, tt.record})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 0 {
+				t.Fatalf("rows = %+v, want none", rows)
+			}
+			if len(rowErrors) != 1 || rowErrors[0].Line != 2 {
+				t.Fatalf("rowErrors = %+v, want one error on line 2", rowErrors)
+			}
+			if !reflect.DeepEqual(rowErrors[0].Errors, []string{tt.want}) {
+				t.Fatalf("errors = %q, want %q", rowErrors[0].Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecordsDuplicateCode(t *testing.T) {
+	records := [][]string{
+		header,
+		{"SKU-1", "Shoe", "", "", "", "100", "1", ""},
+		{"sku-1", "Other shoe", "", "", "", "100", "1", ""},
+	}
+	rows, rowErrors, err := ParseRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Line != 2 {
+		t.Fatalf("rows = %+v, want only line 2", rows)
+	}
+	want := []models.ImportRowError{{Line: 3, Code: "sku-1", Errors: []string{"kode sku-1 sudah dipakai di baris 2"}}}
+	if !reflect.DeepEqual(rowErrors, want) {
+		t.Fatalf("rowErrors = %+v, want %+v", rowErrors, want)
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	products := []models.Product{
+		{Code: "00123", Name: "Air <Max> & co", Brand: "Nike", Category: "Running", Color: "Black", Price: money.IDR(1250000), Stock: 4, ImageURL: "http://localhost:3000/uploads/air.png"},
+		{Code: "SKU-2", Name: "Ultraboost", Brand: "Adidas", Category: "Running", Price: money.IDR(2000000), Stock: 0},
+	}
+	records := ProductRecords(products)
+
+	var buf bytes.Buffer
+	if err := Write(FormatXLSX, &buf, records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(FormatXLSX, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Fatalf("records = %q, want %q", got, records)
+	}
+
+	rows, rowErrors, err := ParseRecords(got)
+	if err != nil || len(rowErrors) != 0 || len(rows) != 2 {
+		t.Fatalf("rows = %+v, rowErrors = %+v, err = %v", rows, rowErrors, err)
+	}
+	if rows[0].Code != "00123" || rows[0].Image != "air.png" || rows[0].Price != money.IDR(1250000) {
+		t.Fatalf("row = %+v", rows[0])
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	records := ProductRecords([]models.Product{{Code: "SKU-1", Name: "Shoe, \"limited\"", Price: money.IDR(100), Stock: 1}})
+
+	var buf bytes.Buffer
+	if err := Write(FormatCSV, &buf, records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(FormatCSV, append([]byte("\xef\xbb\xbf"), buf.Bytes()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Fatalf("records = %q, want %q", got, records)
+	}
+}
+
+// sheetXLSX membuat file XLSX minimal dengan sheetData yang diberikan
+func sheetXLSX(t *testing.T, sheetData string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`))
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		wantErr error
+	}{
+		{"huge row index", `<row r="1000000000"><c r="A1000000000" t="inlineStr"><is><t>x</t></is></c></row>`, ErrTooManyRows},
+		{"row past limit", `<row r="100001"><c r="A100001"><v>1</v></c></row>`, ErrTooManyRows},
+		{"huge column ref", `<row r="1"><c r="ZZZZZZZ1"><v>1</v></c></row>`, errInvalidXLSX},
+		{"bad shared string", `<row r="1"><c r="A1" t="s"><v>5</v></c></row>`, errInvalidXLSX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(FormatXLSX, sheetXLSX(t, tt.sheet))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadXLSXIgnoresFarColumns(t *testing.T) {
+	got, err := Read(FormatXLSX, sheetXLSX(t, `<row r="2"><c r="B2"><v>7</v></c><c r="XFD2"><v>1</v></c></row>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{nil, {"", "7"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("records = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXDecompressionLimit(t *testing.T) {
+	// Sel kosong berulang terkompresi sangat kecil tetapi melebihi batas setelah dekompresi
+	cells := strings.Repeat(`<c r="A1"/>`, maxXMLPartSize/len(`<c r="A1"/>`)+1)
+	_, err := Read(FormatXLSX, sheetXLSX(t, `<row r="1">`+cells+`</row>`))
+	if !errors.Is(err, errXLSXTooLarge) {
+		t.Fatalf("err = %v, want errXLSXTooLarge", err)
+	}
+}
+
+func TestReadCSVTooManyRows(t *testing.T) {
+	data := strings.Repeat("a\n", MaxRecords+1)
+	if _, err := Read(FormatCSV, []byte(data)); err != ErrTooManyRows {
+		t.Fatalf("err = %v, want ErrTooManyRows", err)
+	}
+}
@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format file katalog yang didukung
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes adalah MIME type setiap format untuk header unduhan
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// MaxRecords adalah jumlah baris terbanyak dalam satu file katalog, termasuk header
+const MaxRecords = 100000
+
+// ErrTooManyRows dikembalikan jika file katalog melebihi MaxRecords baris
+var ErrTooManyRows = fmt.Errorf("file katalog melebihi %d baris", MaxRecords)
+
+// DetectFormat menentukan format dari ekstensi nama file
+func DetectFormat(fileName string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("format file tidak didukung, gunakan CSV atau XLSX")
+}
+
+// Read membaca isi file katalog menjadi baris dan kolom
+func Read(format string, data []byte) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(data)
+	case FormatXLSX:
+		return readXLSX(data)
+	}
+	return nil, fmt.Errorf("format %q tidak didukung", format)
+}
+
+// Write menulis baris dan kolom ke w dalam format yang diminta
+func Write(format string, w io.Writer, records [][]string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return cw.Error()
+	case FormatXLSX:
+		return writeXLSX(w, records)
+	}
+	return fmt.Errorf("format %q tidak didukung", format)
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// BOM dari Excel dibuang agar nama kolom pertama tetap dikenali
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file CSV tidak valid: %w", err)
+		}
+		if len(records) == MaxRecords {
+			return nil, ErrTooManyRows
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,80 @@
+// Command stepupctl menjalankan tugas administrasi StepUp dari terminal:
+// membuat atau mempromosikan admin, reset password, seeding produk demo,
+// migrasi database, membersihkan keranjang lama, ekspor order ke CSV, dan
+// rekonsiliasi ledger inventaris dengan stok produk.
+//
+// Konfigurasi dibaca dari .env yang sama dengan server HTTP.
+package main
+
+import (
+	"be-stepup/config"
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// cliActor dicatat sebagai pelaku perubahan data yang dilakukan lewat stepupctl
+const cliActor = repository.SystemActor + ":stepupctl"
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"admin-create", "admin-create -email EMAIL -password PASSWORD [-name NAME]", createAdmin},
+	{"admin-promote", "admin-promote -email EMAIL", promoteAdmin},
+	{"reset-password", "reset-password -email EMAIL -password PASSWORD", resetPassword},
+	{"seed-products", "seed-products [-dir uploads] [-base-url URL]", seedProducts},
+	{"migrate", "migrate [status]", migrate},
+	{"purge-carts", "purge-carts [-older-than 720h]", purgeCarts},
+	{"export-orders", "export-orders [-o orders.csv]", exportOrders},
+	{"reconcile-inventory", "reconcile-inventory [-fix]", reconcileInventory},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: stepupctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			selected = &commands[i]
+			break
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := config.ConnectDB(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	err := selected.run(ctx, os.Args[2:])
+	cancel()
+	config.DisconnectDB()
+
+	if err != nil {
+		log.Fatalf("%s: %v", selected.name, err)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"be-stepup/config"
+	"be-stepup/migrations"
+	"be-stepup/models"
+	"be-stepup/repository"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+func migrate(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "status" {
+		statuses, err := migrations.List(ctx, config.GetDatabase())
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-50s  %s\n", s.Version, s.Description, state)
+		}
+		return nil
+	}
+
+	if err := migrations.Run(ctx, config.GetDatabase()); err != nil {
+		return err
+	}
+	fmt.Println("Migrations are up to date")
+	return nil
+}
+
+func purgeCarts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("purge-carts", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "delete carts not modified for this long")
+	fs.Parse(args)
+
+	deleted, err := repository.DeleteCartsModifiedBefore(ctx, time.Now().Add(-*olderThan))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Purged %d abandoned carts\n", deleted)
+	return nil
+}
+
+func exportOrders(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export-orders", flag.ExitOnError)
+	output := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	checkouts, err := repository.FindAllCheckouts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"checkout_id", "user_id", "user_name", "status", "items", "quantity", "total_price", "currency", "address", "phone_number", "created_at"})
+	for _, checkout := range checkouts {
+		quantity := 0
+		for _, item := range checkout.Items {
+			quantity += item.Quantity
+		}
+		w.Write([]string{
+			checkout.CheckoutID,
+			checkout.UserID,
+			checkout.UserName,
+			checkout.Status,
+			strconv.Itoa(len(checkout.Items)),
+			strconv.Itoa(quantity),
+			checkout.TotalPrice.DecimalString(),
+			checkout.TotalPrice.Currency,
+			checkout.Address,
+			checkout.PhoneNumber,
+			checkout.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d orders to %s\n", len(checkouts), *output)
+	}
+	return nil
+}
+
+func reconcileInventory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reconcile-inventory", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "record an adjustment for every mismatched product so the ledger matches current stock")
+	fs.Parse(args)
+
+	products, err := repository.FindAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+	balances, err := repository.InventoryBalances(ctx)
+	if err != nil {
+		return err
+	}
+
+	mismatched := 0
+	for _, product := range products {
+		balance := balances[product.ProductID]
+		if balance.Total == product.Stock && balance.LastResultingStock == product.Stock {
+			continue
+		}
+		mismatched++
+		fmt.Printf("%-14s stock=%d ledger_total=%d last_resulting=%d movements=%d\n",
+			product.Code, product.Stock, balance.Total, balance.LastResultingStock, balance.Movements)
+
+		if *fix {
+			err := repository.InsertInventoryMovement(ctx, &models.InventoryMovement{
+				ProductID:      product.ProductID,
+				ProductCode:    product.Code,
+				Type:           models.MovementAdjustment,
+				Delta:          product.Stock - balance.Total,
+				ResultingStock: product.Stock,
+				Actor:          cliActor,
+				Note:           "reconciliation",
+			})
+			if err != nil {
+				return fmt.Errorf("failed to fix %s: %w", product.Code, err)
+			}
+		}
+	}
+
+	fmt.Printf("Checked %d products, %d mismatched\n", len(products), mismatched)
+	if mismatched > 0 && !*fix {
+		return fmt.Errorf("inventory ledger does not match current stock")
+	}
+	return nil
+}
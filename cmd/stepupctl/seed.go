@@ -0,0 +1,89 @@
+package main
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"be-stepup/package/slug"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"be-stepup/shipping"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// demoProducts adalah katalog contoh yang memakai gambar di folder uploads/
+var demoProducts = []struct {
+	code, name, color, image string
+	price                    int64
+	stock                    int
+}{
+	{"CRLB", "Compass Retrograde Low Black", "Black", "CRLB.jpg", 598000, 25},
+	{"CRLC", "Compass Retrograde Low Cream", "Cream", "CRLC.jpg", 598000, 25},
+	{"CRLW", "Compass Retrograde Low White", "White", "CRLW.jpeg", 598000, 25},
+	{"CRLCH", "Compass Retrograde Low Chocolate", "Chocolate", "CRLCH.jpg", 618000, 15},
+	{"CRLTB", "Compass Retrograde Low Triple Black", "Triple Black", "CRLTB.jpg", 618000, 15},
+	{"CRBH", "Compass Retrograde High Black", "Black", "CRBH.jpg", 648000, 10},
+}
+
+func seedProducts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seed-products", flag.ExitOnError)
+	dir := fs.String("dir", "uploads", "directory containing the demo images")
+	baseURL := fs.String("base-url", "http://localhost:3000", "public URL the server is reachable at")
+	fs.Parse(args)
+
+	now := time.Now()
+	brand, err := repository.EnsureBrand(ctx, models.Brand{
+		BrandID: "BRAND-" + uuid.New().String()[:8], Name: "Compass", Slug: slug.Make("Compass"),
+		CreatedAt: now, ModifiedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed brand: %w", err)
+	}
+	category, err := repository.EnsureCategory(ctx, models.Category{
+		CategoryID: "CAT-" + uuid.New().String()[:8], Name: "Sneakers", Slug: slug.Make("Sneakers"),
+		CreatedAt: now, ModifiedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed category: %w", err)
+	}
+
+	for _, demo := range demoProducts {
+		if _, err := os.Stat(filepath.Join(*dir, demo.image)); err != nil {
+			return fmt.Errorf("image for %s not found: %w", demo.code, err)
+		}
+
+		product := models.Product{
+			ProductID:   "PROD-" + uuid.New().String()[:8],
+			Code:        "SKU-" + demo.code,
+			Name:        demo.name,
+			Description: "Demo product " + demo.name,
+			BrandID:     brand.BrandID,
+			Brand:       brand.Name,
+			CategoryID:  category.CategoryID,
+			Category:    category.Name,
+			Color:       demo.color,
+			Price:       money.IDR(demo.price),
+			Stock:       demo.stock,
+			WeightGrams: shipping.DefaultItemWeightGrams,
+			ImageURL:    fmt.Sprintf("%s/uploads/%s", strings.TrimSuffix(*baseURL, "/"), demo.image),
+		}
+
+		created, err := productservice.UpsertByCode(ctx, product, cliActor)
+		if err != nil {
+			return fmt.Errorf("failed to seed %s: %w", product.Code, err)
+		}
+
+		action := "updated"
+		if created {
+			action = "created"
+		}
+		fmt.Printf("%-10s %s %s\n", product.Code, action, product.Name)
+	}
+	return nil
+}
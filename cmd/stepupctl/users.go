@@ -0,0 +1,99 @@
+package main
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+func createAdmin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("admin-create", flag.ExitOnError)
+	email := fs.String("email", "", "email for the new admin")
+	password := fs.String("password", "", "password for the new admin")
+	name := fs.String("name", "Administrator", "display name")
+	fs.Parse(args)
+
+	if *email == "" || *password == "" {
+		return errors.New("-email and -password are required")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("could not hash password: %w", err)
+	}
+
+	user := models.User{
+		UserID:    uuid.New().String(),
+		Email:     *email,
+		Password:  string(hashedPassword),
+		Role:      models.RoleAdmin,
+		Name:      *name,
+		CreatedAt: time.Now(),
+	}
+	err = repository.InsertUser(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("email %s is already registered, use admin-promote instead", *email)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %s (%s)\n", user.Email, user.UserID)
+	return nil
+}
+
+func promoteAdmin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("admin-promote", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user to promote")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	err := repository.UpdateUserByEmail(ctx, *email, bson.M{"role": models.RoleAdmin})
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("user %s not found", *email)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Promoted %s to admin\n", *email)
+	return nil
+}
+
+func resetPassword(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user")
+	password := fs.String("password", "", "new password")
+	fs.Parse(args)
+
+	if *email == "" || *password == "" {
+		return errors.New("-email and -password are required")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("could not hash password: %w", err)
+	}
+
+	err = repository.UpdateUserByEmail(ctx, *email, bson.M{"password": string(hashedPassword)})
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("user %s not found", *email)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Password reset for %s\n", *email)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"time"
 )
 
 func Config(key string) string {
@@ -15,4 +16,19 @@ func Config(key string) string {
 	return os.Getenv(key)
 }
 
+// ConfigDuration membaca durasi (misalnya "10s" atau "1m") dari environment.
+// Nilai fallback dipakai jika variabel kosong atau tidak valid.
+func ConfigDuration(key string, fallback time.Duration) time.Duration {
+	value := Config(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		fmt.Printf("Invalid duration for %s: %q, using %s\n", key, value, fallback)
+		return fallback
+	}
+	return duration
+}
+
 var AuthSecret = Config("AUTH_SECRET")
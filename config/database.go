@@ -29,9 +29,14 @@ func ConnectDB() error {
 	return nil
 }
 
+// GetDatabase returns a reference to the application database
+func GetDatabase() *mongo.Database {
+	return Client.Database("stepupDB")
+}
+
 // GetCollection returns a reference to a MongoDB collection
 func GetCollection(collectionName string) *mongo.Collection {
-	return Client.Database("stepupDB").Collection(collectionName)
+	return GetDatabase().Collection(collectionName)
 }
 
 // DisconnectDB closes the MongoDB connection
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxSavedAddresses membatasi jumlah alamat di buku alamat satu user
+const maxSavedAddresses = 20
+
+// GetMyAddresses mengembalikan buku alamat milik user yang sedang login
+func GetMyAddresses(c *fiber.Ctx) error {
+	user, err := repository.FindUserByID(c.UserContext(), c.Locals("userID").(string))
+	if err != nil {
+		return currentUserError(c, err)
+	}
+
+	addresses := user.Addresses
+	if addresses == nil {
+		addresses = []models.SavedAddress{}
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    addresses,
+	})
+}
+
+// CreateMyAddress menambahkan alamat baru; alamat pertama otomatis menjadi alamat utama
+func CreateMyAddress(c *fiber.Ctx) error {
+	address, ok := parseSavedAddress(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	if _, err := repository.FindUserByID(ctx, userID); err != nil {
+		return currentUserError(c, err)
+	}
+
+	now := time.Now()
+	requestedDefault := address.IsDefault
+	address.AddressID = uuid.New().String()
+	address.IsDefault = false
+	address.CreatedAt = now
+	address.ModifiedAt = now
+
+	// Batas jumlah alamat diperiksa di filter update agar dua request bersamaan tidak melewatinya
+	err := repository.PushUserAddress(ctx, userID, address, maxSavedAddresses)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Jumlah alamat tersimpan sudah mencapai batas",
+		})
+	}
+	if err == nil {
+		if requestedDefault {
+			err = repository.SetDefaultUserAddress(ctx, userID, address.AddressID)
+		} else {
+			err = repository.EnsureDefaultUserAddress(ctx, userID)
+		}
+	}
+	if err != nil {
+		log.Printf("Error saving addresses for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyimpan alamat",
+		})
+	}
+
+	return savedAddressResponse(c, userID, address.AddressID, http.StatusCreated, "Alamat berhasil ditambahkan")
+}
+
+// UpdateMyAddress mengganti isi alamat tersimpan berdasarkan address_id
+func UpdateMyAddress(c *fiber.Ctx) error {
+	update, ok := parseSavedAddress(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	update.AddressID = c.Params("address_id")
+	update.ModifiedAt = time.Now()
+
+	err := repository.UpdateUserAddress(ctx, userID, update)
+	// Alamat utama hanya bisa dipindah dengan menjadikan alamat lain sebagai utama
+	if err == nil && update.IsDefault {
+		err = repository.SetDefaultUserAddress(ctx, userID, update.AddressID)
+	}
+	if err == mongo.ErrNoDocuments {
+		return addressNotFound(c)
+	}
+	if err != nil {
+		log.Printf("Error saving addresses for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui alamat",
+		})
+	}
+
+	return savedAddressResponse(c, userID, update.AddressID, http.StatusOK, "Alamat berhasil diperbarui")
+}
+
+// SetMyDefaultAddress menjadikan satu alamat sebagai alamat utama
+func SetMyDefaultAddress(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	addressID := c.Params("address_id")
+
+	err := repository.SetDefaultUserAddress(ctx, userID, addressID)
+	if err == mongo.ErrNoDocuments {
+		return addressNotFound(c)
+	}
+	if err != nil {
+		log.Printf("Error saving addresses for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui alamat utama",
+		})
+	}
+
+	return savedAddressResponse(c, userID, addressID, http.StatusOK, "Alamat utama berhasil diperbarui")
+}
+
+// DeleteMyAddress menghapus alamat tersimpan; jika alamat utama dihapus, alamat tertua menjadi utama
+func DeleteMyAddress(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+
+	err := repository.PullUserAddress(ctx, userID, c.Params("address_id"))
+	if err == mongo.ErrNoDocuments {
+		return addressNotFound(c)
+	}
+	if err == nil {
+		err = repository.EnsureDefaultUserAddress(ctx, userID)
+	}
+	if err != nil {
+		log.Printf("Error saving addresses for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus alamat",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Alamat berhasil dihapus",
+	})
+}
+
+// savedAddressResponse memuat ulang buku alamat dan mengirim alamat addressID, sehingga response
+// ikut menampilkan status alamat utama setelah update
+func savedAddressResponse(c *fiber.Ctx, userID, addressID string, status int, message string) error {
+	user, err := repository.FindUserByID(c.UserContext(), userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+	index := findSavedAddress(user.Addresses, addressID)
+	if index < 0 {
+		// Alamat sudah dihapus request lain setelah diubah
+		return addressNotFound(c)
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    user.Addresses[index],
+	})
+}
+
+func addressNotFound(c *fiber.Ctx) error {
+	return c.Status(http.StatusNotFound).JSON(fiber.Map{
+		"success": false,
+		"error":   "Alamat tidak ditemukan",
+	})
+}
+
+// parseSavedAddress membaca dan memvalidasi body alamat; response error sudah dikirim jika ok false
+func parseSavedAddress(c *fiber.Ctx) (models.SavedAddress, bool) {
+	var address models.SavedAddress
+	if err := c.BodyParser(&address); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+		return address, false
+	}
+	if err := validate.Struct(address); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+		return address, false
+	}
+	return address, true
+}
+
+func findSavedAddress(addresses []models.SavedAddress, addressID string) int {
+	for i, address := range addresses {
+		if address.AddressID == addressID {
+			return i
+		}
+	}
+	return -1
+}
+
+// defaultAddress mengembalikan alamat utama user, atau false jika buku alamat kosong
+func defaultAddress(addresses []models.SavedAddress) (models.SavedAddress, bool) {
+	for _, address := range addresses {
+		if address.IsDefault {
+			return address, true
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0], true
+	}
+	return models.SavedAddress{}, false
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// AdminListProducts mengembalikan semua produk termasuk draft, arsip, dan produk terhapus (khusus admin).
+// Filter: ?status=, ?deleted=true|false, ?category_id=, ?brand_id=
+func AdminListProducts(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	switch c.Query("deleted") {
+	case "true":
+		filter["deleted_at"] = bson.M{"$ne": nil}
+	case "false":
+		filter["deleted_at"] = nil
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		filter["category_id"] = categoryID
+	}
+	if brandID := c.Query("brand_id"); brandID != "" {
+		filter["brand_id"] = brandID
+	}
+
+	products, total, err := repository.ListProducts(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing products: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       products,
+		"pagination": page,
+	})
+}
+
+// AdminGetProduct mengembalikan satu produk apa pun statusnya (khusus admin)
+func AdminGetProduct(c *fiber.Ctx) error {
+	product, ok := findAdminProduct(c)
+	if !ok {
+		return nil
+	}
+
+	setETag(c, product.Version)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+		"visible": product.Available(time.Now()),
+	})
+}
+
+// AdminUpdateProductStatus mengubah status dan jadwal tayang produk (khusus admin)
+func AdminUpdateProductStatus(c *fiber.Ctx) error {
+	var req models.ProductStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	product, ok := findAdminProduct(c)
+	if !ok {
+		return nil
+	}
+	if product.DeletedAt != nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk sudah dihapus, pulihkan produk terlebih dahulu",
+		})
+	}
+
+	switch checkVersion(c, req.Version, product.Version) {
+	case http.StatusPreconditionFailed:
+		setETag(c, product.Version)
+		return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+			"success": false,
+			"error":   "Versi produk tidak cocok dengan If-Match",
+		})
+	case http.StatusConflict:
+		setETag(c, product.Version)
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk sudah diubah oleh request lain, muat ulang lalu coba lagi",
+		})
+	}
+
+	status, msg := normalizeProductSchedule(req.Status, req.PublishAt, req.UnpublishAt, time.Now())
+	if msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   msg,
+		})
+	}
+
+	ctx := c.UserContext()
+	product, err := productservice.SetStatus(ctx, product, status, req.PublishAt, req.UnpublishAt, requestActor(c))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk sudah diubah oleh request lain, muat ulang lalu coba lagi",
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating status of productID %s: %v\n", product.ProductID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui status produk",
+		})
+	}
+
+	// Produk yang tidak lagi tayang dikeluarkan dari keranjang seperti produk yang dihapus
+	if !product.Available(time.Now()) {
+		if _, err := repository.RemoveProductFromCarts(ctx, product.ProductID); err != nil {
+			log.Printf("Error removing productID %s from carts: %v\n", product.ProductID, err)
+		}
+	}
+
+	setETag(c, product.Version)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Status produk berhasil diperbarui",
+		"data":    product,
+	})
+}
+
+// AdminRestoreProduct memulihkan produk yang dihapus dengan status sebelum dihapus (khusus admin)
+func AdminRestoreProduct(c *fiber.Ctx) error {
+	product, ok := findAdminProduct(c)
+	if !ok {
+		return nil
+	}
+
+	product, err := productservice.Restore(c.UserContext(), product, requestActor(c))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk tidak dalam keadaan terhapus",
+		})
+	}
+	if err != nil {
+		log.Printf("Error restoring productID %s: %v\n", product.ProductID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memulihkan produk",
+		})
+	}
+
+	setETag(c, product.Version)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Produk berhasil dipulihkan",
+		"data":    product,
+	})
+}
+
+// findAdminProduct mengambil produk dari parameter :id termasuk yang terhapus;
+// response error sudah dikirim jika ok false
+func findAdminProduct(c *fiber.Ctx) (models.Product, bool) {
+	var product models.Product
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "ID produk tidak valid",
+		})
+		return product, false
+	}
+
+	product, err = repository.FindProductByObjectID(c.UserContext(), id)
+	if err == mongo.ErrNoDocuments {
+		c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk tidak ditemukan",
+		})
+		return product, false
+	}
+	if err != nil {
+		c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+		return product, false
+	}
+	return product, true
+}
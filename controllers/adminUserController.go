@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"be-stepup/audit"
+	"be-stepup/models"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"regexp"
+)
+
+// AdminListUsers mengembalikan daftar user dengan paginasi, bisa difilter dengan ?role= dan ?q=
+func AdminListUsers(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	filter := bson.M{}
+	if role := c.Query("role"); role != "" {
+		filter["role"] = role
+	}
+	if q := c.Query("q"); q != "" {
+		pattern := containsPattern(q)
+		filter["$or"] = bson.A{bson.M{"email": pattern}, bson.M{"name": pattern}}
+	}
+
+	users, total, err := repository.ListUsers(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing users: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan daftar user",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       users,
+		"pagination": page,
+	})
+}
+
+// AdminCreateStaff membuat akun staff atau admin baru
+func AdminCreateStaff(c *fiber.Ctx) error {
+	var req models.CreateStaffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	user, err := createUser(c.UserContext(), req.Name, req.Email, req.Password, req.Role)
+	if err == errEmailInUse {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Email sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating staff account: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat akun",
+		})
+	}
+
+	audit.Record(c, models.AuditEvent{
+		Action:     models.AuditUserCreate,
+		TargetType: "user",
+		TargetID:   user.UserID,
+		Details:    map[string]interface{}{"role": user.Role},
+	})
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Akun berhasil dibuat",
+		"data":    user,
+	})
+}
+
+// AdminUpdateUserRole mengubah role user
+func AdminUpdateUserRole(c *fiber.Ctx) error {
+	var req struct {
+		Role string `json:"role" validate:"required,oneof=user staff admin"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	userID := c.Params("id")
+	if userID == c.Locals("userID").(string) && req.Role != models.RoleAdmin {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Admin tidak dapat menurunkan role miliknya sendiri",
+		})
+	}
+
+	if previous, err := repository.FindUserByID(c.UserContext(), userID); err == nil {
+		audit.Detail(c, "previous_role", previous.Role)
+	}
+	audit.Detail(c, "role", req.Role)
+
+	return adminUpdateUser(c, userID, bson.M{"role": req.Role})
+}
+
+// AdminUpdateUserStatus menonaktifkan atau mengaktifkan kembali akun user
+func AdminUpdateUserStatus(c *fiber.Ctx) error {
+	var req struct {
+		Disabled *bool `json:"disabled" validate:"required"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	userID := c.Params("id")
+	if userID == c.Locals("userID").(string) && *req.Disabled {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Admin tidak dapat menonaktifkan akunnya sendiri",
+		})
+	}
+
+	audit.Detail(c, "disabled", *req.Disabled)
+
+	return adminUpdateUser(c, userID, bson.M{"disabled": *req.Disabled})
+}
+
+// adminUpdateUser menerapkan perubahan lalu mengembalikan data user terbaru
+func adminUpdateUser(c *fiber.Ctx, userID string, fields bson.M) error {
+	ctx := c.UserContext()
+
+	err := repository.UpdateUserByID(ctx, userID, fields)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "User tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating user %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui user",
+		})
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan user",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User berhasil diperbarui",
+		"data":    user,
+	})
+}
+
+// containsPattern membuat pencarian case-insensitive yang aman dari karakter regex khusus
+func containsPattern(q string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(q), "$options": "i"}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"net/http"
+	"time"
+)
+
+// AdminListAuditEvents mengembalikan event audit dengan paginasi (khusus admin).
+// Filter: ?actor=, ?action=, ?target_type=, ?target_id=, ?outcome=, ?request_id=,
+// ?from= dan ?to= (RFC3339)
+func AdminListAuditEvents(c *fiber.Ctx) error {
+	filter := bson.M{}
+	for _, field := range []string{"actor", "action", "target_type", "target_id", "outcome", "request_id"} {
+		if value := c.Query(field); value != "" {
+			filter[field] = value
+		}
+	}
+	createdAt, msg := createdAtRange(c)
+	if msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   msg,
+		})
+	}
+	if createdAt != nil {
+		filter["created_at"] = createdAt
+	}
+
+	page := parsePagination(c)
+	events, total, err := repository.ListAuditEvents(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing audit events: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan event audit",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       events,
+		"pagination": page,
+	})
+}
+
+// createdAtRange membentuk filter created_at dari ?from= dan ?to= berformat RFC3339.
+// Mengembalikan nil jika keduanya kosong, atau pesan kesalahan jika formatnya salah.
+func createdAtRange(c *fiber.Ctx) (bson.M, string) {
+	createdAt := bson.M{}
+	for _, bound := range []struct{ param, op string }{{"from", "$gte"}, {"to", "$lte"}} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, "Parameter " + bound.param + " harus berformat RFC3339"
+		}
+		createdAt[bound.op] = t
+	}
+	if len(createdAt) == 0 {
+		return nil, ""
+	}
+	return createdAt, ""
+}
@@ -1,66 +1,86 @@
 package controllers
 
 import (
-	"be-stepup/config"
+	"be-stepup/audit"
 	"be-stepup/models"
 	jwtoken "be-stepup/package/token"
+	"be-stepup/repository"
 	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"time"
 )
 
+// errEmailInUse menandakan email sudah terdaftar
+var errEmailInUse = errors.New("email already in use")
+
+// createUser hashes the password and stores a new user with the given role.
+// The role is always chosen by the server, never taken from the request body.
+func createUser(ctx context.Context, name, email, password, role string) (models.User, error) {
+	if _, err := repository.FindUserByEmail(ctx, email); err == nil {
+		return models.User{}, errEmailInUse
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		UserID:    uuid.New().String(),
+		Email:     email,
+		Password:  string(hashedPassword),
+		Role:      role,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	// Unique index on email guards against concurrent registrations
+	err = repository.InsertUser(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return models.User{}, errEmailInUse
+	}
+	return user, err
+}
+
 // Register handles user registration
 func Register(c *fiber.Ctx) error {
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
+	var req models.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
 
-	// Check if the email already exists
-	collection := config.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var existingUser models.User
-	err := collection.FindOne(ctx, map[string]string{"email": user.Email}).Decode(&existingUser)
-	if err == nil {
-		return c.Status(http.StatusConflict).JSON(fiber.Map{
-			"error": "Email already in use",
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid data: " + err.Error(),
 		})
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not hash password",
+	user, err := createUser(c.UserContext(), req.Name, req.Email, req.Password, models.RoleUser)
+	if err == errEmailInUse {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"error": "Email already in use",
 		})
 	}
-	user.Password = string(hashedPassword)
-
-	// Set default values for new user
-	user.CreatedAt = time.Now()       // Set created_at
-	user.UserID = uuid.New().String() // Generate user ID
-
-	// Insert user into database
-	_, err = collection.InsertOne(ctx, user)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Could not create user",
 		})
 	}
 
-	// Remove sensitive data from response
-	user.Password = ""
+	// Keranjang yang dibuat sebelum registrasi dipindahkan ke akun baru
+	cartWarnings := mergeGuestCartOnLogin(c, user.UserID)
 
 	return c.JSON(fiber.Map{
-		"message": "User registered successfully",
-		"user":    user,
+		"message":       "User registered successfully",
+		"user":          user,
+		"cart_warnings": cartWarnings,
 	})
 }
 
@@ -78,13 +98,13 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	// Fetch user from database
-	collection := config.GetCollection("users")
-	var user models.User
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err := collection.FindOne(ctx, map[string]string{"email": loginReq.Email}).Decode(&user)
+	user, err := repository.FindUserByEmail(c.UserContext(), loginReq.Email)
 	if err != nil {
+		reason := "unknown_email"
+		if err != mongo.ErrNoDocuments {
+			reason = "lookup_failed"
+		}
+		recordLogin(c, "", loginReq.Email, models.AuditFailure, reason)
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid email or password",
 		})
@@ -93,11 +113,19 @@ func Login(c *fiber.Ctx) error {
 	// Compare password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginReq.Password))
 	if err != nil {
+		recordLogin(c, user.UserID, loginReq.Email, models.AuditFailure, "wrong_password")
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid email or password",
 		})
 	}
 
+	if user.Disabled {
+		recordLogin(c, user.UserID, loginReq.Email, models.AuditFailure, "account_disabled")
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"error": "Account is disabled",
+		})
+	}
+
 	token, err := jwtoken.GenerateJWT(user.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -105,11 +133,35 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
+	recordLogin(c, user.UserID, loginReq.Email, models.AuditSuccess, "")
+
+	// Keranjang tamu digabung ke keranjang user
+	cartWarnings := mergeGuestCartOnLogin(c, user.UserID)
+
 	// Respond with user role
 	return c.JSON(fiber.Map{
-		"message": "Login successful",
-		"role":    user.Role,
-		"name":    user.Name,
-		"token":   token,
+		"message":       "Login successful",
+		"role":          user.Role,
+		"name":          user.Name,
+		"token":         token,
+		"cart_warnings": cartWarnings,
 	})
 }
+
+// recordLogin mencatat percobaan login ke audit. Pelaku login yang berhasil adalah user itu
+// sendiri; percobaan yang gagal dicatat sebagai anonim dengan email yang dicoba.
+func recordLogin(c *fiber.Ctx, userID, email, outcome, reason string) {
+	event := models.AuditEvent{
+		Action:     models.AuditLogin,
+		TargetType: "user",
+		TargetID:   userID,
+		Outcome:    outcome,
+		Details:    map[string]interface{}{"email": email},
+	}
+	if outcome == models.AuditSuccess {
+		event.Actor = userID
+	} else {
+		event.Details["reason"] = reason
+	}
+	audit.Record(c, event)
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/slug"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// brandEntry adalah merek beserta jumlah produknya
+type brandEntry struct {
+	models.Brand
+	ProductCount int64 `json:"product_count"`
+}
+
+// GetAllBrands mengembalikan semua merek beserta jumlah produk
+func GetAllBrands(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	brands, err := repository.ListBrands(ctx)
+	if err != nil {
+		log.Printf("Error listing brands: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan merek",
+		})
+	}
+	counts, err := repository.CountAvailableProductsByBrand(ctx, time.Now())
+	if err != nil {
+		log.Printf("Error counting products by brand: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung produk per merek",
+		})
+	}
+
+	entries := make([]brandEntry, 0, len(brands))
+	for _, brand := range brands {
+		entries = append(entries, brandEntry{Brand: brand, ProductCount: counts[brand.BrandID]})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// GetBrandByID mengembalikan satu merek
+func GetBrandByID(c *fiber.Ctx) error {
+	brand, err := repository.FindBrandByID(c.UserContext(), c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Merek tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan merek",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    brand,
+	})
+}
+
+// CreateBrand membuat merek baru (admin)
+func CreateBrand(c *fiber.Ctx) error {
+	request, ok := parseBrandRequest(c)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	brand := models.Brand{
+		BrandID:    "BRAND-" + uuid.New().String()[:8],
+		Name:       request.Name,
+		Slug:       request.Slug,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+
+	err := repository.InsertBrand(c.UserContext(), brand)
+	if mongo.IsDuplicateKeyError(err) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug merek sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating brand: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat merek",
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Merek berhasil dibuat",
+		"data":    brand,
+	})
+}
+
+// UpdateBrand mengubah nama atau slug merek (admin). Nama baru ikut disalin ke produk.
+func UpdateBrand(c *fiber.Ctx) error {
+	request, ok := parseBrandRequest(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	brand, err := repository.FindBrandByID(ctx, c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Merek tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan merek",
+		})
+	}
+
+	renamed := brand.Name != request.Name
+	brand.Name = request.Name
+	brand.Slug = request.Slug
+	brand.ModifiedAt = time.Now()
+
+	err = repository.UpdateBrand(ctx, brand)
+	if mongo.IsDuplicateKeyError(err) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug merek sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating brand %s: %v\n", brand.BrandID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui merek",
+		})
+	}
+
+	if renamed {
+		if err := repository.RenameProductsBrand(ctx, brand.BrandID, brand.Name); err != nil {
+			log.Printf("Error renaming brand %s on products: %v\n", brand.BrandID, err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Merek berhasil diperbarui",
+		"data":    brand,
+	})
+}
+
+// DeleteBrand menghapus merek yang tidak digunakan oleh produk mana pun (admin)
+func DeleteBrand(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	brandID := c.Params("id")
+
+	counts, err := repository.CountProductsByBrand(ctx)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus merek",
+		})
+	}
+	if counts[brandID] > 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Merek masih digunakan oleh produk",
+		})
+	}
+
+	err = repository.DeleteBrand(ctx, brandID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Merek tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus merek",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Merek berhasil dihapus",
+	})
+}
+
+// parseBrandRequest membaca dan memvalidasi body merek; response error sudah dikirim jika ok false
+func parseBrandRequest(c *fiber.Ctx) (models.BrandRequest, bool) {
+	var request models.BrandRequest
+	if err := c.BodyParser(&request); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+		return request, false
+	}
+	if request.Slug == "" {
+		request.Slug = slug.Make(request.Name)
+	}
+	if err := validate.Struct(request); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+		return request, false
+	}
+	if !slug.Valid(request.Slug) {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug hanya boleh berisi huruf kecil, angka, dan tanda hubung",
+		})
+		return request, false
+	}
+	return request, true
+}
@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"be-stepup/cartservice"
 	"be-stepup/config"
 	"be-stepup/models"
+	"be-stepup/package/money"
+	"be-stepup/promotion"
+	"be-stepup/repository"
 	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,18 +20,17 @@ import (
 
 // GetAllCart menangani pengambilan semua item dalam keranjang
 func GetAllCart(c *fiber.Ctx) error {
-	// Mendapatkan userID dari token (dari middleware JWT)
-	userID := c.Locals("userID").(string)
-	log.Println("UserID:", userID) // Menambahkan log untuk melihat nilai userID
+	// Pemilik keranjang adalah user dari token JWT atau tamu dari token keranjang
+	ownerID := cartOwner(c)
+	log.Println("Cart owner:", ownerID) // Menambahkan log untuk melihat pemilik keranjang
 
 	// Mengambil koleksi `cart`
 	collection := config.GetCollection("cart")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
-	// Mencari keranjang berdasarkan userID
+	// Mencari keranjang berdasarkan pemiliknya
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	err := collection.FindOne(ctx, bson.M{"user_id": ownerID}).Decode(&cart)
 	if err != nil {
 		log.Println("Error finding cart:", err) // Menambahkan log error untuk mencari cart
 		if err == mongo.ErrNoDocuments {
@@ -39,10 +43,8 @@ func GetAllCart(c *fiber.Ctx) error {
 		})
 	}
 
-	// Mengambil nama pengguna
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err = userCollection.FindOne(ctx, bson.M{"userid": userID}).Decode(&user)
+	// Mengambil nama pengguna (kosong untuk tamu)
+	userName, err := cartUserName(ctx, ownerID)
 	if err != nil {
 		log.Println("Error finding user:", err) // Menambahkan log error untuk mencari user
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -50,12 +52,68 @@ func GetAllCart(c *fiber.Ctx) error {
 		})
 	}
 
+	// Mencocokkan setiap item dengan data produk terbaru
+	productIDs := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := repository.FindProductsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mendapatkan produk",
+		})
+	}
+	items, warnings, changed := cartService.Reconcile(cart.Items, availableProducts(products, time.Now()))
+	if changed {
+		cart.Items = items
+		cart.ModifiedAt = time.Now()
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"user_id": ownerID},
+			bson.M{"$set": bson.M{"items": cart.Items, "modified_at": cart.ModifiedAt}},
+		)
+		if err != nil {
+			log.Println("Error saving reconciled cart:", err)
+		}
+	}
+
+	// Subtotal dan total hanya menghitung item yang stoknya tersedia; ongkos kirim dihitung saat checkout
+	var lines []promotion.Line
+	for _, item := range items {
+		product := products[item.ProductID]
+		if product.Stock <= 0 {
+			continue
+		}
+		lines = append(lines, promotion.Line{
+			ProductID:  product.ProductID,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			UnitPrice:  product.Price,
+		})
+	}
+	pricing, err := applyPromotions(ctx, ownerID, lines, "")
+	if err != nil {
+		log.Println("Error applying promotions:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghitung total keranjang",
+		})
+	}
+	if len(lines) == 0 {
+		pricing.Subtotal = money.New(0, money.DefaultCurrency)
+		pricing.Discount = pricing.Subtotal
+		pricing.Total = pricing.Subtotal
+	}
+
 	// Mengembalikan item-item di dalam keranjang
 	return c.JSON(fiber.Map{
 		"cart_id":     cart.CartID,
 		"user_id":     cart.UserID,
-		"user_name":   user.Name, // Menambahkan nama pengguna
+		"user_name":   userName, // Menambahkan nama pengguna
 		"items":       cart.Items,
+		"warnings":    warnings,
+		"subtotal":    pricing.Subtotal,
+		"discount":    pricing.Discount,
+		"discounts":   pricing.Applied,
+		"total":       pricing.Total,
 		"created_at":  cart.CreatedAt,
 		"modified_at": cart.ModifiedAt,
 	})
@@ -66,134 +124,156 @@ func generateUniqueID() string {
 	return uuid.New().String()
 }
 
+// cartService menerapkan aturan jumlah item yang sama untuk semua handler keranjang
+var cartService = cartservice.NewFromConfig()
+
+// errProductNotFound dikembalikan addToCart agar pemanggil bisa memilih status HTTP
+var errProductNotFound = errors.New("produk tidak ditemukan")
+
 // AddToCart menangani penambahan item ke keranjang
 func AddToCart(c *fiber.Ctx) error {
-	// Mengambil data dari body request
-	var cartItem models.CartItem
-	if err := c.BodyParser(&cartItem); err != nil {
+	// Mengambil data dari body request; hanya product_id dan quantity yang dipakai,
+	// harga dan detail produk selalu diambil dari koleksi products
+	var request struct {
+		ProductID string `json:"product_id"`
+		Quantity  int    `json:"quantity"`
+	}
+	if err := c.BodyParser(&request); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "Body request tidak valid",
 		})
 	}
 
-	// Mendapatkan userID dari token (dari middleware JWT)
-	userID := c.Locals("userID").(string)
-	log.Println("UserID:", userID) // Menambahkan log untuk melihat nilai userID
+	// Pemilik keranjang adalah user dari token JWT atau tamu dari token keranjang
+	ownerID := cartOwner(c)
+	log.Println("Cart owner:", ownerID) // Menambahkan log untuk melihat pemilik keranjang
+
+	items, err := addToCart(c.UserContext(), ownerID, request.ProductID, request.Quantity)
+	if err != nil {
+		return addToCartError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Item berhasil ditambahkan ke keranjang",
+		"cart":    items,
+	})
+}
 
+// addToCart menambahkan produk ke keranjang milik ownerID (user atau tamu) setelah memeriksa
+// produk, stok, dan batas pembelian, lalu mengembalikan isi keranjang terbaru.
+// Dipakai oleh AddToCart dan wishlist.
+func addToCart(ctx context.Context, ownerID, productID string, quantity int) ([]models.CartItem, error) {
 	// Mengambil koleksi `cart`
 	collection := config.GetCollection("cart")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
 
 	// Mengambil data pengguna untuk mendapatkan user_name
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"userid": userID}).Decode(&user)
+	userName, err := cartUserName(ctx, ownerID)
 	if err != nil {
 		log.Println("Error finding user:", err) // Menambahkan log error untuk mencari user
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mendapatkan nama pengguna",
-		})
+		return nil, err
 	}
 
 	// Mencari produk berdasarkan ProductID
-	productCollection := config.GetCollection("products")
-	var product models.Product
-	err = productCollection.FindOne(ctx, bson.M{"product_id": cartItem.ProductID}).Decode(&product)
+	product, err := repository.FindProductByProductID(ctx, productID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": "Produk tidak ditemukan",
-			})
+			return nil, errProductNotFound
 		}
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mendapatkan produk",
-		})
+		return nil, err
 	}
-
-	// Mengecek apakah stok cukup
-	if product.Stock < cartItem.Quantity {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Stok produk tidak cukup",
-		})
+	if !product.Available(time.Now()) {
+		return nil, errProductNotFound
 	}
 
-	// Mencari keranjang berdasarkan userID
+	// Mencari keranjang berdasarkan pemiliknya
 	var cart models.Cart
-	err = collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	err = collection.FindOne(ctx, bson.M{"user_id": ownerID}).Decode(&cart)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	isNew := err == mongo.ErrNoDocuments
 
+	// Stok dan batas pembelian diperiksa terhadap jumlah akhir di keranjang
+	items, err := cartService.Add(cart.Items, product, quantity)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			// Jika keranjang belum ada, buat keranjang baru dengan user_name
-			cartItem.ProductCode = product.Code
-			cartItem.ProductName = product.Name
-			cartItem.ImageURL = product.ImageURL
-
-			cart = models.Cart{
-				CartID:     generateUniqueID(),
-				UserID:     userID,
-				UserName:   user.Name, // Menyimpan user_name
-				Items:      []models.CartItem{cartItem},
-				CreatedAt:  time.Now(),
-				ModifiedAt: time.Now(),
-			}
-			_, err := collection.InsertOne(ctx, cart)
-			if err != nil {
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Gagal membuat keranjang baru",
-				})
-			}
-			return c.JSON(fiber.Map{
-				"message": "Item berhasil ditambahkan ke keranjang",
-				"cart":    cart.Items,
-			})
-		}
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mendapatkan keranjang",
-		})
+		return nil, err
 	}
 
-	// Menambahkan atau memperbarui item dalam keranjang
-	itemFound := false
-	for i, item := range cart.Items {
-		if item.ProductID == cartItem.ProductID {
-			// Jika item ditemukan, tambahkan kuantitas
-			cart.Items[i].Quantity += cartItem.Quantity
-			itemFound = true
-			break
+	if isNew {
+		// Jika keranjang belum ada, buat keranjang baru dengan user_name
+		cart = models.Cart{
+			CartID:     generateUniqueID(),
+			UserID:     ownerID,
+			UserName:   userName, // Menyimpan user_name
+			Items:      items,
+			CreatedAt:  time.Now(),
+			ModifiedAt: time.Now(),
 		}
-	}
-
-	if !itemFound {
-		// Tambahkan item baru dengan informasi produk yang diperlukan
-		cartItem.ProductCode = product.Code
-		cartItem.ProductName = product.Name
-		cartItem.ImageURL = product.ImageURL
-
-		cart.Items = append(cart.Items, cartItem)
+		if _, err := collection.InsertOne(ctx, cart); err != nil {
+			return nil, err
+		}
+		return cart.Items, nil
 	}
 
 	// Perbarui keranjang di database dengan user_name
 	_, err = collection.UpdateOne(
 		ctx,
-		bson.M{"user_id": userID},
+		bson.M{"user_id": ownerID},
 		bson.M{"$set": bson.M{
-			"items":       cart.Items,
-			"user_name":   user.Name, // Update user_name di cart
+			"items":       items,
+			"user_name":   userName, // Update user_name di cart
 			"modified_at": time.Now(),
 		}})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal memperbarui keranjang",
-		})
+		return nil, err
 	}
+	return items, nil
+}
 
-	return c.JSON(fiber.Map{
-		"message": "Item berhasil ditambahkan ke keranjang",
-		"cart":    cart.Items,
+// addToCartError memetakan error addToCart dan aturan keranjang ke response HTTP
+func addToCartError(c *fiber.Ctx, err error) error {
+	var maxErr *cartservice.MaxQuantityError
+	switch {
+	case err == errProductNotFound:
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Produk tidak ditemukan",
+		})
+	case err == cartservice.ErrItemNotFound:
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Item tidak ditemukan di keranjang",
+		})
+	case err == cartservice.ErrInsufficientStock:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Stok produk tidak cukup",
+		})
+	case err == cartservice.ErrInvalidQuantity, errors.As(err, &maxErr):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	log.Println("Error updating cart:", err)
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Gagal memperbarui keranjang",
 	})
 }
 
+// saveCartItems menyimpan isi keranjang; keranjang yang kosong dihapus dari koleksi
+func saveCartItems(ctx context.Context, ownerID string, items []models.CartItem) error {
+	collection := config.GetCollection("cart")
+	if len(items) == 0 {
+		_, err := collection.DeleteOne(ctx, bson.M{"user_id": ownerID})
+		return err
+	}
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.M{"user_id": ownerID},
+		bson.M{"$set": bson.M{
+			"items":       items,
+			"modified_at": time.Now(),
+		}})
+	return err
+}
+
 // RemoveSingleCartItem menangani penghapusan satu item dari keranjang
 func RemoveSingleCartItem(c *fiber.Ctx) error {
 	// Parse request body
@@ -207,17 +287,16 @@ func RemoveSingleCartItem(c *fiber.Ctx) error {
 		})
 	}
 
-	// Mendapatkan userID dari token (dari middleware JWT)
-	userID := c.Locals("userID").(string)
+	// Pemilik keranjang adalah user dari token JWT atau tamu dari token keranjang
+	ownerID := cartOwner(c)
 
 	// Mengambil koleksi `cart`
 	collection := config.GetCollection("cart")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
-	// Mencari keranjang berdasarkan userID
+	// Mencari keranjang berdasarkan pemiliknya
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	err := collection.FindOne(ctx, bson.M{"user_id": ownerID}).Decode(&cart)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": "Keranjang tidak ditemukan",
@@ -225,54 +304,26 @@ func RemoveSingleCartItem(c *fiber.Ctx) error {
 	}
 
 	// Menghapus item dari keranjang
-	itemFound := false
-	updatedItems := []models.CartItem{}
-
-	for _, item := range cart.Items {
-		if item.ProductID == request.ProductID {
-			itemFound = true
-			continue // Hapus item dari keranjang
-		}
-		updatedItems = append(updatedItems, item)
+	updatedItems, err := cartService.Remove(cart.Items, request.ProductID)
+	if err != nil {
+		return addToCartError(c, err)
 	}
 
-	if !itemFound {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Item tidak ditemukan di keranjang",
+	// Jika Items kosong setelah penghapusan, dokumen keranjang ikut dihapus
+	if err := saveCartItems(ctx, ownerID, updatedItems); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal memperbarui keranjang",
 		})
 	}
 
-	// Jika Items kosong setelah penghapusan, hapus dokumen keranjang dari koleksi
-	if len(updatedItems) == 0 {
-		_, err := collection.DeleteOne(ctx, bson.M{"user_id": userID})
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Gagal menghapus keranjang kosong",
-			})
-		}
-	} else {
-		// Perbarui keranjang dengan item yang sudah diperbarui
-		_, err := collection.UpdateOne(
-			ctx,
-			bson.M{"user_id": userID},
-			bson.M{"$set": bson.M{
-				"items":       updatedItems,
-				"modified_at": time.Now(),
-			}})
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Gagal memperbarui keranjang",
-			})
-		}
-	}
-
 	return c.JSON(fiber.Map{
 		"message": "Item berhasil dihapus dari keranjang",
 		"cart":    updatedItems,
 	})
 }
 
-// UpdateCartItem menangani pembaruan item di keranjang
+// UpdateCartItem menangani pembaruan item di keranjang. Jumlah 0 atau kurang menghapus item;
+// jumlah baru tidak boleh melebihi stok maupun batas pembelian produk.
 func UpdateCartItem(c *fiber.Ctx) error {
 	// Parse request body untuk mendapatkan data pembaruan
 	var updateItemRequest struct {
@@ -286,50 +337,42 @@ func UpdateCartItem(c *fiber.Ctx) error {
 		})
 	}
 
-	// Mendapatkan userID dari token (dari middleware JWT)
-	userID := c.Locals("userID").(string)
+	// Pemilik keranjang adalah user dari token JWT atau tamu dari token keranjang
+	ownerID := cartOwner(c)
 
 	// Mengambil koleksi `cart`
 	collection := config.GetCollection("cart")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
-	// Mencari keranjang berdasarkan userID
+	// Mencari keranjang berdasarkan pemiliknya
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	err := collection.FindOne(ctx, bson.M{"user_id": ownerID}).Decode(&cart)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": "Keranjang tidak ditemukan",
 		})
 	}
 
-	// Mencari item dalam keranjang berdasarkan ProductID dan memperbarui kuantitasnya
-	itemFound := false
-	for i, item := range cart.Items {
-		if item.ProductID == updateItemRequest.ProductID {
-			// Jika item ditemukan, perbarui kuantitasnya
-			cart.Items[i].Quantity = updateItemRequest.Quantity
-			itemFound = true
-			break
+	// Menghapus item tidak memerlukan data produk, sehingga produk yang sudah dihapus tetap bisa dikeluarkan
+	var items []models.CartItem
+	if updateItemRequest.Quantity <= 0 {
+		items, err = cartService.Remove(cart.Items, updateItemRequest.ProductID)
+	} else {
+		product, findErr := repository.FindProductByProductID(ctx, updateItemRequest.ProductID)
+		if findErr == mongo.ErrNoDocuments || (findErr == nil && !product.Available(time.Now())) {
+			findErr = errProductNotFound
 		}
+		if findErr != nil {
+			return addToCartError(c, findErr)
+		}
+		items, err = cartService.SetQuantity(cart.Items, product, updateItemRequest.Quantity)
 	}
-
-	// Jika item tidak ditemukan dalam keranjang
-	if !itemFound {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Item tidak ditemukan di keranjang",
-		})
+	if err != nil {
+		return addToCartError(c, err)
 	}
 
 	// Perbarui keranjang di database dengan kuantitas baru
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"user_id": userID},
-		bson.M{"$set": bson.M{
-			"items":       cart.Items,
-			"modified_at": time.Now(),
-		}})
-	if err != nil {
+	if err := saveCartItems(ctx, ownerID, items); err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal memperbarui keranjang",
 		})
@@ -337,6 +380,77 @@ func UpdateCartItem(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"message": "Item berhasil diperbarui",
-		"cart":    cart.Items,
+		"cart":    items,
+	})
+}
+
+// PreviewCart menghitung subtotal, diskon promosi, dan total keranjang tanpa membuat checkout
+func PreviewCart(c *fiber.Ctx) error {
+	var request struct {
+		VoucherCode string `json:"voucher_code"`
+	}
+	if err := c.BodyParser(&request); err != nil && len(c.Body()) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Body request tidak valid",
+		})
+	}
+
+	userID := c.Locals("userID").(string)
+	ctx := c.UserContext()
+
+	var cart models.Cart
+	err := config.GetCollection("cart").FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Keranjang tidak ditemukan",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mendapatkan keranjang",
+		})
+	}
+
+	// Harga dan kategori diambil dari data produk terbaru
+	productCollection := config.GetCollection("products")
+	var lines []promotion.Line
+	for _, item := range cart.Items {
+		var product models.Product
+		err := productCollection.FindOne(ctx, bson.M{"product_id": item.ProductID}).Decode(&product)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal mendapatkan produk",
+			})
+		}
+		if !product.Available(time.Now()) {
+			continue
+		}
+		lines = append(lines, promotion.Line{
+			ProductID:  product.ProductID,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			UnitPrice:  product.Price,
+		})
+	}
+
+	pricing, err := applyPromotions(ctx, userID, lines, request.VoucherCode)
+	if err != nil {
+		if isPromotionError(err) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println("Error applying promotions:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghitung promosi",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"items":   cart.Items,
+		"pricing": pricing,
 	})
 }
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"be-stepup/catalog"
+	"be-stepup/middleware"
+	"be-stepup/models"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"be-stepup/shipping"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxImportFileSize membatasi ukuran file katalog, sama dengan batas body bawaan Fiber
+	maxImportFileSize = 4 << 20
+	// maxImportRowErrors membatasi jumlah kesalahan baris yang disimpan di dokumen job
+	maxImportRowErrors = 1000
+	// importProgressEvery menentukan seberapa sering progres job disimpan
+	importProgressEvery = 50
+)
+
+// AdminImportProducts menerima file katalog CSV/XLSX lalu menjalankan impor di latar belakang.
+// Produk dicocokkan berdasarkan code: kode baru dibuat, kode lama diperbarui. Dengan
+// ?dry_run=true file hanya divalidasi tanpa menyimpan produk (khusus admin).
+func AdminImportProducts(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "File katalog tidak ditemukan",
+		})
+	}
+	if fileHeader.Size > maxImportFileSize {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Ukuran file katalog maksimal 4MB",
+		})
+	}
+	format, err := catalog.DetectFormat(fileHeader.Filename)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membaca file katalog",
+		})
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membaca file katalog",
+		})
+	}
+
+	// Kesalahan format dan header langsung dilaporkan; kesalahan per baris masuk ke laporan job
+	records, err := catalog.Read(format, data)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	rows, rowErrors, err := catalog.ParseRecords(records)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	job := models.ImportJob{
+		JobID:     uuid.New().String(),
+		Status:    models.ImportQueued,
+		DryRun:    c.QueryBool("dry_run", false),
+		FileName:  fileHeader.Filename,
+		Format:    format,
+		Actor:     requestActor(c),
+		TotalRows: len(rows) + len(rowErrors),
+		RowErrors: []models.ImportRowError{},
+		CreatedAt: time.Now(),
+	}
+	for _, rowError := range rowErrors {
+		addImportRowError(&job, rowError)
+	}
+	job.ProcessedRows = job.Failed
+
+	if err := repository.InsertImportJob(c.UserContext(), job); err != nil {
+		log.Printf("Error creating import job: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat job impor",
+		})
+	}
+
+	ctx, cancel := middleware.Detach(c)
+	go func() {
+		defer cancel()
+		runProductImport(ctx, job, rows)
+	}()
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Impor produk sedang diproses",
+		"data":    job,
+	})
+}
+
+// AdminGetImportJob mengembalikan progres dan laporan kesalahan job impor (khusus admin)
+func AdminGetImportJob(c *fiber.Ctx) error {
+	job, err := repository.FindImportJob(c.UserContext(), c.Params("job_id"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Job impor tidak ditemukan",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan job impor",
+		})
+	}
+
+	progress := 100.0
+	if job.TotalRows > 0 {
+		progress = float64(job.ProcessedRows*1000/job.TotalRows) / 10
+	}
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"data":     job,
+		"progress": progress,
+	})
+}
+
+// AdminExportProducts mengunduh seluruh katalog dalam format yang sama dengan impor,
+// ?format=csv (bawaan) atau ?format=xlsx (khusus admin)
+func AdminExportProducts(c *fiber.Ctx) error {
+	format := c.Query("format", catalog.FormatCSV)
+	contentType, ok := catalog.ContentTypes[format]
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Format harus csv atau xlsx",
+		})
+	}
+
+	products, err := repository.FindAllProducts(c.UserContext())
+	if err != nil {
+		log.Printf("Error exporting products: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := catalog.Write(format, &buf, catalog.ProductRecords(products)); err != nil {
+		log.Printf("Error writing product export: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat file ekspor",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fmt.Sprintf("products-%s.%s", time.Now().Format("20060102"), format))
+	return c.Send(buf.Bytes())
+}
+
+// runProductImport menyimpan setiap baris valid dan memperbarui progres job secara berkala
+func runProductImport(ctx context.Context, job models.ImportJob, rows []catalog.Row) {
+	startedAt := time.Now()
+	job.Status = models.ImportRunning
+	job.StartedAt = &startedAt
+	saveImportProgress(ctx, job)
+
+	for i, row := range rows {
+		created, err := importProductRow(ctx, row, job.DryRun, job.Actor)
+		if err != nil {
+			addImportRowError(&job, models.ImportRowError{Line: row.Line, Code: row.Code, Errors: []string{err.Error()}})
+		} else if created {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+		job.ProcessedRows++
+
+		if ctx.Err() != nil {
+			job.Status = models.ImportFailed
+			job.Error = "Impor dihentikan karena server dimatikan"
+			break
+		}
+		if (i+1)%importProgressEvery == 0 {
+			saveImportProgress(ctx, job)
+		}
+	}
+
+	if job.Status == models.ImportRunning {
+		job.Status = models.ImportCompleted
+	}
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	// Progres akhir tetap disimpan walaupun context impor sudah dibatalkan
+	saveImportProgress(context.WithoutCancel(ctx), job)
+}
+
+// importProductRow membuat atau memperbarui satu produk; created bernilai true untuk kode baru.
+// Saat dry run hanya pemeriksaan yang dijalankan.
+func importProductRow(ctx context.Context, row catalog.Row, dryRun bool, actor string) (created bool, err error) {
+	if row.Image != "" {
+		if _, err := os.Stat(filepath.Join(uploadDir, row.Image)); err != nil {
+			return false, fmt.Errorf("gambar %s tidak ditemukan di folder %s", row.Image, uploadDir)
+		}
+	}
+
+	// Merek dan kategori harus sudah terdaftar; nama di file dicocokkan lewat slug
+	product := row.Product()
+	switch err := resolveProductTaxonomy(ctx, &product); err {
+	case nil:
+	case errCategoryNotFound:
+		return false, fmt.Errorf("kategori %s belum terdaftar", row.Category)
+	case errBrandNotFound:
+		return false, fmt.Errorf("merek %s belum terdaftar", row.Brand)
+	default:
+		return false, fmt.Errorf("gagal mendapatkan kategori atau merek: %v", err)
+	}
+
+	existing, err := repository.FindProductByCode(ctx, row.Code)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, fmt.Errorf("gagal mendapatkan produk: %v", err)
+	}
+	created = err == mongo.ErrNoDocuments
+	if dryRun {
+		return created, nil
+	}
+
+	product.ImageURL = existing.ImageURL
+	if row.Image != "" {
+		product.ImageURL = fmt.Sprintf("http://localhost:3000/%s/%s", uploadDir, row.Image)
+	}
+	productID := existing.ProductID
+	if created {
+		productID = "PROD-" + uuid.New().String()[:8]
+		product.ProductID = productID
+		product.WeightGrams = shipping.DefaultItemWeightGrams
+	}
+
+	upserted, err := productservice.UpsertDetails(ctx, product, actor)
+	if err != nil {
+		return false, fmt.Errorf("gagal menyimpan produk: %v", err)
+	}
+	// Kode yang sama bisa saja dibuat bersamaan oleh proses lain sejak pemeriksaan di atas
+	if created && !upserted {
+		if existing, err = repository.FindProductByCode(ctx, row.Code); err != nil {
+			return false, fmt.Errorf("gagal mendapatkan produk: %v", err)
+		}
+		productID = existing.ProductID
+	}
+	created = upserted
+
+	movementType := models.MovementAdjustment
+	if created {
+		movementType = models.MovementRestock
+	}
+	movement, changed, err := productservice.SetStock(ctx, productID, row.Stock, models.InventoryMovement{
+		Type:  movementType,
+		Actor: actor,
+		Note:  "catalog import",
+	})
+	if err != nil {
+		return created, fmt.Errorf("gagal menyimpan stok: %v", err)
+	}
+	if changed {
+		raiseLowStockAlert(ctx, movement)
+	}
+	return created, nil
+}
+
+// addImportRowError mencatat baris yang gagal; laporan dibatasi agar dokumen job tidak terlalu besar
+func addImportRowError(job *models.ImportJob, rowError models.ImportRowError) {
+	job.Failed++
+	if len(job.RowErrors) < maxImportRowErrors {
+		job.RowErrors = append(job.RowErrors, rowError)
+	}
+}
+
+// saveImportProgress menyimpan progres job; kegagalan hanya dicatat agar impor tetap berjalan
+func saveImportProgress(ctx context.Context, job models.ImportJob) {
+	if err := repository.SaveImportJob(ctx, job); err != nil {
+		log.Printf("Error saving progress of import job %s: %v\n", job.JobID, err)
+	}
+}
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/slug"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// categoryEntry adalah kategori beserta jumlah produknya. TotalProductCount ikut menghitung
+// produk di semua subkategori.
+type categoryEntry struct {
+	models.Category
+	ProductCount      int64 `json:"product_count"`
+	TotalProductCount int64 `json:"total_product_count"`
+}
+
+// GetAllCategories mengembalikan semua kategori beserta jumlah produk; hierarki dibentuk dari parent_id
+func GetAllCategories(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	categories, err := repository.ListCategories(ctx)
+	if err != nil {
+		log.Printf("Error listing categories: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan kategori",
+		})
+	}
+	counts, err := repository.CountAvailableProductsByCategory(ctx, time.Now())
+	if err != nil {
+		log.Printf("Error counting products by category: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung produk per kategori",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    categoryEntries(categories, counts),
+	})
+}
+
+// GetCategoryByID mengembalikan satu kategori beserta subkategori langsungnya
+func GetCategoryByID(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	category, err := repository.FindCategoryByID(ctx, c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan kategori",
+		})
+	}
+
+	categories, err := repository.ListCategories(ctx)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan kategori",
+		})
+	}
+	counts, err := repository.CountAvailableProductsByCategory(ctx, time.Now())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung produk per kategori",
+		})
+	}
+
+	var entry categoryEntry
+	children := []categoryEntry{}
+	for _, e := range categoryEntries(categories, counts) {
+		switch {
+		case e.CategoryID == category.CategoryID:
+			entry = e
+		case e.ParentID == category.CategoryID:
+			children = append(children, e)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"data":     entry,
+		"children": children,
+	})
+}
+
+// CreateCategory membuat kategori baru (admin)
+func CreateCategory(c *fiber.Ctx) error {
+	request, ok := parseCategoryRequest(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	if request.ParentID != "" {
+		if _, err := repository.FindCategoryByID(ctx, request.ParentID); err != nil {
+			return categoryParentError(c, err)
+		}
+	}
+
+	now := time.Now()
+	category := models.Category{
+		CategoryID: "CAT-" + uuid.New().String()[:8],
+		Name:       request.Name,
+		Slug:       request.Slug,
+		ParentID:   request.ParentID,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+
+	err := repository.InsertCategory(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug kategori sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating category: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat kategori",
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Kategori berhasil dibuat",
+		"data":    category,
+	})
+}
+
+// UpdateCategory mengubah nama, slug, atau induk kategori (admin). Nama baru ikut disalin ke produk.
+func UpdateCategory(c *fiber.Ctx) error {
+	request, ok := parseCategoryRequest(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	category, err := repository.FindCategoryByID(ctx, c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan kategori",
+		})
+	}
+
+	if request.ParentID != "" {
+		if _, err := repository.FindCategoryByID(ctx, request.ParentID); err != nil {
+			return categoryParentError(c, err)
+		}
+		categories, err := repository.ListCategories(ctx)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "Gagal mendapatkan kategori",
+			})
+		}
+		if createsCategoryCycle(categories, category.CategoryID, request.ParentID) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Kategori tidak boleh menjadi subkategori dari dirinya sendiri",
+			})
+		}
+	}
+
+	renamed := category.Name != request.Name
+	category.Name = request.Name
+	category.Slug = request.Slug
+	category.ParentID = request.ParentID
+	category.ModifiedAt = time.Now()
+
+	err = repository.UpdateCategory(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug kategori sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating category %s: %v\n", category.CategoryID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui kategori",
+		})
+	}
+
+	if renamed {
+		if err := repository.RenameProductsCategory(ctx, category.CategoryID, category.Name); err != nil {
+			log.Printf("Error renaming category %s on products: %v\n", category.CategoryID, err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kategori berhasil diperbarui",
+		"data":    category,
+	})
+}
+
+// DeleteCategory menghapus kategori yang tidak memiliki subkategori maupun produk (admin)
+func DeleteCategory(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	categoryID := c.Params("id")
+
+	children, err := repository.CountChildCategories(ctx, categoryID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus kategori",
+		})
+	}
+	if children > 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori masih memiliki subkategori",
+		})
+	}
+	counts, err := repository.CountProductsByCategory(ctx)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus kategori",
+		})
+	}
+	if counts[categoryID] > 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori masih digunakan oleh produk",
+		})
+	}
+
+	err = repository.DeleteCategory(ctx, categoryID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus kategori",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kategori berhasil dihapus",
+	})
+}
+
+// parseCategoryRequest membaca dan memvalidasi body kategori; response error sudah dikirim jika ok false
+func parseCategoryRequest(c *fiber.Ctx) (models.CategoryRequest, bool) {
+	var request models.CategoryRequest
+	if err := c.BodyParser(&request); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+		return request, false
+	}
+	if request.Slug == "" {
+		request.Slug = slug.Make(request.Name)
+	}
+	if err := validate.Struct(request); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+		return request, false
+	}
+	if !slug.Valid(request.Slug) {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Slug hanya boleh berisi huruf kecil, angka, dan tanda hubung",
+		})
+		return request, false
+	}
+	return request, true
+}
+
+// categoryParentError mengirim response untuk parent_id yang tidak bisa dipakai
+func categoryParentError(c *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kategori induk tidak ditemukan",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Gagal mendapatkan kategori induk",
+	})
+}
+
+// createsCategoryCycle bernilai true jika categoryID berada di rantai induk parentID
+func createsCategoryCycle(categories []models.Category, categoryID, parentID string) bool {
+	parents := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryID] = category.ParentID
+	}
+	// Batas langkah menjaga loop tetap berhenti walaupun data lama sudah berputar
+	for id, steps := parentID, 0; id != "" && steps <= len(categories); id, steps = parents[id], steps+1 {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryEntries menggabungkan kategori dengan jumlah produk langsung dan seluruh turunannya
+func categoryEntries(categories []models.Category, counts map[string]int64) []categoryEntry {
+	parents := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryID] = category.ParentID
+	}
+
+	totals := make(map[string]int64, len(categories))
+	for _, category := range categories {
+		count := counts[category.CategoryID]
+		for id, steps := category.CategoryID, 0; id != "" && steps <= len(categories); id, steps = parents[id], steps+1 {
+			totals[id] += count
+		}
+	}
+
+	entries := make([]categoryEntry, 0, len(categories))
+	for _, category := range categories {
+		entries = append(entries, categoryEntry{
+			Category:          category,
+			ProductCount:      counts[category.CategoryID],
+			TotalProductCount: totals[category.CategoryID],
+		})
+	}
+	return entries
+}
@@ -1,9 +1,15 @@
 package controllers
 
 import (
+	"be-stepup/audit"
+	"be-stepup/cartservice"
 	"be-stepup/config"
 	"be-stepup/models"
-	"context"
+	"be-stepup/package/money"
+	"be-stepup/productservice"
+	"be-stepup/promotion"
+	"be-stepup/repository"
+	"be-stepup/shipping"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,6 +22,9 @@ import (
 
 var validate = validator.New()
 
+// checkoutPaymentWindow adalah lama waktu user untuk membayar sebelum checkout dibatalkan otomatis
+var checkoutPaymentWindow = config.ConfigDuration("CHECKOUT_PAYMENT_WINDOW", 24*time.Hour)
+
 // Fungsi untuk membuat ID unik khusus checkout
 func generateCheckoutID() string {
 	return uuid.New().String()
@@ -25,8 +34,17 @@ func generateCheckoutID() string {
 func CreateCheckout(c *fiber.Ctx) error {
 	// Parse request body
 	var checkoutRequest struct {
-		Address     string `json:"address" validate:"required,min=5,max=100"`
-		PhoneNumber string `json:"phone_number" validate:"required,min=10,max=15"`
+		// AddressID memilih alamat dari buku alamat; jika kosong dipakai ShippingAddress,
+		// dan jika keduanya kosong dipakai alamat utama user
+		AddressID       string          `json:"address_id"`
+		ShippingAddress *models.Address `json:"shipping_address" validate:"omitempty"`
+		RecipientName   string          `json:"recipient_name" validate:"omitempty,max=100"`
+		PhoneNumber     string          `json:"phone_number" validate:"required_with=ShippingAddress,omitempty,min=10,max=15"`
+		Courier         string          `json:"courier" validate:"required"`
+		CourierService  string          `json:"courier_service" validate:"required"`
+		// AcceptPriceChanges dikirim true setelah user melihat dan menyetujui perubahan harga
+		AcceptPriceChanges bool   `json:"accept_price_changes"`
+		VoucherCode        string `json:"voucher_code" validate:"omitempty,max=32"`
 	}
 
 	if err := c.BodyParser(&checkoutRequest); err != nil {
@@ -52,16 +70,56 @@ func CreateCheckout(c *fiber.Ctx) error {
 			"error":   "User tidak terautentikasi",
 		})
 	}
-	log.Println("UserID:", userID)
 
 	// Mengambil koleksi `cart`
 	cartCollection := config.GetCollection("cart")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
+
+	// Mengambil data pengguna untuk user_name dan buku alamat
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error finding user for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan nama pengguna",
+		})
+	}
+
+	// Menentukan alamat pengiriman; alamat disalin ke checkout sehingga perubahan buku alamat tidak memengaruhinya
+	var destination models.SavedAddress
+	switch {
+	case checkoutRequest.AddressID != "":
+		index := findSavedAddress(user.Addresses, checkoutRequest.AddressID)
+		if index < 0 {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Alamat tidak ditemukan",
+			})
+		}
+		destination = user.Addresses[index]
+	case checkoutRequest.ShippingAddress != nil:
+		destination = models.SavedAddress{
+			RecipientName: checkoutRequest.RecipientName,
+			PhoneNumber:   checkoutRequest.PhoneNumber,
+			Address:       *checkoutRequest.ShippingAddress,
+		}
+		if destination.RecipientName == "" {
+			destination.RecipientName = user.Name
+		}
+	default:
+		var found bool
+		destination, found = defaultAddress(user.Addresses)
+		if !found {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Alamat pengiriman wajib diisi",
+			})
+		}
+	}
 
 	// Mencari keranjang berdasarkan userID
 	var cart models.Cart
-	err := cartCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	err = cartCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -72,8 +130,11 @@ func CreateCheckout(c *fiber.Ctx) error {
 	// Mengambil koleksi `products`
 	productCollection := config.GetCollection("products")
 
-	// Menghitung total harga dan mengurangi stok produk
-	var totalPrice float64
+	// Validasi ulang harga dan stok terhadap data produk terbaru sebelum stok dikurangi
+	items := make([]models.CartItem, 0, len(cart.Items))
+	var priceChanges []priceChange
+	var lines []promotion.Line
+	weight := 0
 	for _, item := range cart.Items {
 		var product models.Product
 		err := productCollection.FindOne(ctx, bson.M{"product_id": item.ProductID}).Decode(&product)
@@ -89,62 +150,197 @@ func CreateCheckout(c *fiber.Ctx) error {
 				"error":   "Gagal mendapatkan produk",
 			})
 		}
+		if !product.Available(time.Now()) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Produk " + product.Name + " sudah tidak tersedia",
+			})
+		}
 
-		// Mengecek apakah stok cukup
-		if product.Stock < item.Quantity {
+		// Mengecek stok dan batas pembelian dengan aturan yang sama seperti keranjang
+		if err := cartService.CheckQuantity(product, item.Quantity); err != nil {
+			message := err.Error()
+			if err == cartservice.ErrInsufficientStock {
+				message = "Stok produk tidak cukup untuk " + product.Name
+			}
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
-				"error":   "Stok produk tidak cukup untuk " + product.Name,
+				"error":   message,
 			})
 		}
 
-		// Mengurangi stok produk
-		newStock := product.Stock - item.Quantity
-		_, err = productCollection.UpdateOne(
-			ctx,
-			bson.M{"product_id": item.ProductID},
-			bson.M{"$set": bson.M{"stock": newStock}},
-		)
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		// Harga selalu diambil dari data produk, bukan dari keranjang
+		if !item.Price.Equal(product.Price) {
+			priceChanges = append(priceChanges, priceChange{
+				ProductID:   product.ProductID,
+				ProductName: product.Name,
+				OldPrice:    item.Price,
+				NewPrice:    product.Price,
+			})
+		}
+		item.Price = product.Price
+		item.ProductCode = product.Code
+		item.ProductName = product.Name
+		items = append(items, item)
+		lines = append(lines, promotion.Line{
+			ProductID:  product.ProductID,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			UnitPrice:  product.Price,
+		})
+		weight += shipping.ItemWeight(product) * item.Quantity
+	}
+
+	// Menerapkan promosi otomatis dan voucher
+	pricing, err := applyPromotions(ctx, userID, lines, checkoutRequest.VoucherCode)
+	if err != nil {
+		if isPromotionError(err) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
-				"error":   "Gagal memperbarui stok produk",
+				"error":   err.Error(),
 			})
 		}
+		log.Printf("Error applying promotions for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung promosi",
+		})
+	}
 
-		totalPrice += item.Price * float64(item.Quantity)
+	// Menghitung ongkos kirim untuk kurir dan layanan yang dipilih
+	rate, err := shipping.Quote(ctx, shippingProvider, shipping.RateRequest{
+		Origin:      shippingOrigin,
+		Destination: destination.Address,
+		WeightGrams: weight,
+		Courier:     checkoutRequest.Courier,
+	}, checkoutRequest.CourierService)
+	if err == shipping.ErrServiceNotFound {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		log.Printf("Error quoting shipping for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung ongkos kirim",
+		})
 	}
 
-	// Mengambil data pengguna untuk mendapatkan user_name
-	userCollection := config.GetCollection("users")
-	var user models.User
-	err = userCollection.FindOne(ctx, bson.M{"userid": userID}).Decode(&user)
+	// Promosi gratis ongkir menghapus ongkos kirim
+	shippingDiscount := money.New(0, rate.Fee.Currency)
+	if pricing.FreeShipping {
+		shippingDiscount = rate.Fee
+	}
+	totalPrice, err := pricing.Total.Add(rate.Fee)
+	if err == nil {
+		totalPrice, err = totalPrice.Sub(shippingDiscount)
+	}
 	if err != nil {
-		log.Printf("Error finding user for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Mata uang ongkos kirim tidak sama dengan keranjang",
+		})
+	}
+
+	// Jika harga berubah sejak ditambahkan ke keranjang, perbarui keranjang dan minta konfirmasi user
+	if len(priceChanges) > 0 && !checkoutRequest.AcceptPriceChanges {
+		_, err = cartCollection.UpdateOne(ctx,
+			bson.M{"user_id": userID},
+			bson.M{"$set": bson.M{"items": items, "modified_at": time.Now()}},
+		)
+		if err != nil {
+			log.Printf("Error refreshing cart prices for userID %s: %v\n", userID, err)
+		}
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success":       false,
+			"error":         "Harga beberapa produk telah berubah, silakan periksa kembali keranjang Anda",
+			"price_changes": priceChanges,
+			"total_price":   totalPrice,
+		})
+	}
+
+	// Memesan kuota promosi dan jatah per user sebelum stok dikurangi
+	checkoutID := generateCheckoutID()
+	reservedPromotions, err := reservePromotions(ctx, pricing.Applied, userID, checkoutID)
+	if err != nil {
+		if isPromotionError(err) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		log.Printf("Error reserving promotions for userID %s: %v\n", userID, err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
-			"error":   "Gagal mendapatkan nama pengguna",
+			"error":   "Gagal memproses promosi",
+		})
+	}
+
+	// Mengurangi stok secara atomik dan mencatatnya di ledger; jika gagal di tengah jalan
+	// stok yang sudah dikurangi dikembalikan
+	var reserved []models.CartItem
+	var movements []models.InventoryMovement
+	for _, item := range items {
+		movement, err := productservice.AdjustStock(ctx, models.InventoryMovement{
+			ProductID:   item.ProductID,
+			Type:        models.MovementSale,
+			Delta:       -item.Quantity,
+			Actor:       userID,
+			ReferenceID: checkoutID,
 		})
+		if err != nil {
+			releaseStock(ctx, reserved, userID, checkoutID)
+			releasePromotions(ctx, reservedPromotions, checkoutID)
+			if err != mongo.ErrNoDocuments {
+				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+					"success": false,
+					"error":   "Gagal memperbarui stok produk",
+				})
+			}
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Stok produk tidak cukup untuk " + item.ProductName,
+			})
+		}
+		reserved = append(reserved, item)
+		movements = append(movements, movement)
 	}
 
-	// Membuat checkout baru
+	// Membuat checkout baru; stok yang sudah dikurangi dikembalikan jika tidak dibayar sampai batas waktu
+	now := time.Now()
 	checkout := models.Checkout{
-		CheckoutID:  generateCheckoutID(),
-		UserID:      userID,
-		UserName:    user.Name,
-		Items:       cart.Items,
-		TotalPrice:  totalPrice,
-		Address:     checkoutRequest.Address,
-		PhoneNumber: checkoutRequest.PhoneNumber,
-		Status:      "Pending", // Status awal adalah Pending
-		CreatedAt:   time.Now(),
-		ModifiedAt:  time.Now(),
+		CheckoutID:       checkoutID,
+		UserID:           userID,
+		UserName:         user.Name,
+		Items:            items,
+		Subtotal:         pricing.Subtotal,
+		Discount:         pricing.Discount,
+		Discounts:        pricing.Applied,
+		VoucherCode:      normalizeVoucherCode(checkoutRequest.VoucherCode),
+		TotalPrice:       totalPrice,
+		Address:          destination.Address.String(),
+		ShippingAddress:  destination.Address,
+		RecipientName:    destination.RecipientName,
+		Courier:          rate.Courier,
+		CourierService:   rate.Service,
+		WeightGrams:      weight,
+		ShippingFee:      rate.Fee,
+		ShippingDiscount: shippingDiscount,
+		PhoneNumber:      destination.PhoneNumber,
+		Status:           models.CheckoutStatusPending, // Status awal adalah Pending
+		PaymentDeadline:  now.Add(checkoutPaymentWindow),
+		CreatedAt:        now,
+		ModifiedAt:       now,
 	}
 
 	// Mengambil koleksi `checkout`
 	checkoutCollection := config.GetCollection("checkout")
 	_, err = checkoutCollection.InsertOne(ctx, checkout)
 	if err != nil {
+		releaseStock(ctx, reserved, userID, checkoutID)
+		releasePromotions(ctx, reservedPromotions, checkoutID)
 		log.Printf("Error creating checkout for userID %s: %v\n", userID, err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -152,6 +348,11 @@ func CreateCheckout(c *fiber.Ctx) error {
 		})
 	}
 
+	// Peringatan stok menipis hanya dikirim setelah checkout benar-benar tersimpan
+	for _, movement := range movements {
+		raiseLowStockAlert(ctx, movement)
+	}
+
 	// Hapus keranjang setelah checkout berhasil
 	_, err = cartCollection.DeleteOne(ctx, bson.M{"user_id": userID})
 	if err != nil {
@@ -163,9 +364,11 @@ func CreateCheckout(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Checkout berhasil dibuat",
-		"data":    checkout,
+		"success":       true,
+		"message":       "Checkout berhasil dibuat",
+		"data":          checkout,
+		"payment_due":   paymentDue(checkout, time.Now()),
+		"price_changes": priceChanges,
 	})
 }
 
@@ -176,8 +379,7 @@ func GetCheckoutByID(c *fiber.Ctx) error {
 
 	// Mengambil koleksi `checkout`
 	collection := config.GetCollection("checkout")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	// Mencari checkout berdasarkan checkoutID
 	var checkout models.Checkout
@@ -195,15 +397,35 @@ func GetCheckoutByID(c *fiber.Ctx) error {
 		})
 	}
 
+	setETag(c, checkout.Version)
 	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    checkout,
+		"success":     true,
+		"data":        checkout,
+		"payment_due": paymentDue(checkout, time.Now()),
 	})
 }
 
+// paymentDue menghitung sisa waktu pembayaran untuk checkout Pending; nil untuk checkout lain
+// atau checkout lama yang belum memiliki batas waktu
+func paymentDue(checkout models.Checkout, now time.Time) fiber.Map {
+	if checkout.Status != models.CheckoutStatusPending || checkout.PaymentDeadline.IsZero() {
+		return nil
+	}
+	remaining := checkout.PaymentDeadline.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fiber.Map{
+		"deadline":          checkout.PaymentDeadline,
+		"remaining_seconds": int64(remaining.Seconds()),
+		"expired":           remaining == 0,
+	}
+}
+
 func UpdateCheckout(c *fiber.Ctx) error {
 	var updateRequest struct {
-		Status string `json:"status" validate:"required"`
+		Status  string `json:"status" validate:"required,oneof=Pending Completed Cancelled"`
+		Version *int64 `json:"version"` // Alternatif header If-Match
 	}
 
 	if err := c.BodyParser(&updateRequest); err != nil {
@@ -221,19 +443,43 @@ func UpdateCheckout(c *fiber.Ctx) error {
 	}
 
 	checkoutID := c.Params("checkout_id")
+	ctx := c.UserContext()
 
-	collection := config.GetCollection("checkout")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	_, err := collection.UpdateOne(
-		ctx,
-		bson.M{"checkout_id": checkoutID},
-		bson.M{"$set": bson.M{
-			"status":      updateRequest.Status,
-			"modified_at": time.Now(),
-		}},
-	)
+	checkout, err := repository.FindCheckoutByID(ctx, checkoutID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Checkout tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan checkout",
+		})
+	}
+
+	// Perubahan status dari admin lain atau job pembatalan tidak boleh tertimpa diam-diam
+	if status := checkVersion(c, updateRequest.Version, checkout.Version); status != 0 {
+		return checkoutVersionError(c, status, checkout.Version)
+	}
+	if !checkout.CanTransitionTo(updateRequest.Status) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Status checkout tidak dapat diubah dari " + checkout.Status + " ke " + updateRequest.Status,
+		})
+	}
+
+	// Pembatalan melewati jalur yang sama dengan job checkout-expiry agar stok dan kuota promosi kembali
+	var updated models.Checkout
+	if updateRequest.Status == models.CheckoutStatusCancelled {
+		updated, err = repository.CancelCheckout(ctx, checkoutID, checkout.Version, models.CheckoutCancelAdmin, time.Now())
+	} else {
+		updated, err = repository.UpdateCheckoutStatus(ctx, checkoutID, checkout.Version, updateRequest.Status, time.Now())
+	}
+	if err == mongo.ErrNoDocuments {
+		return checkoutVersionError(c, http.StatusConflict, checkout.Version)
+	}
 	if err != nil {
 		log.Printf("Error updating checkout status for checkoutID %s: %v\n", checkoutID, err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -241,21 +487,44 @@ func UpdateCheckout(c *fiber.Ctx) error {
 			"error":   "Gagal memperbarui status checkout",
 		})
 	}
+	if updated.Status == models.CheckoutStatusCancelled {
+		// Jika gagal, stock_released_at tetap kosong dan job checkout-expiry mengulang pengembaliannya
+		if err := productservice.ReleaseCheckout(ctx, updated, requestActor(c), "cancelled by admin"); err != nil {
+			log.Printf("Error releasing reservations of cancelled checkout %s: %v\n", checkoutID, err)
+		}
+	}
+
+	audit.Detail(c, "previous_status", checkout.Status)
+	audit.Detail(c, "status", updated.Status)
 
+	setETag(c, updated.Version)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Status checkout berhasil diperbarui",
 		"data": fiber.Map{
 			"checkout_id": checkoutID,
-			"status":      updateRequest.Status,
+			"status":      updated.Status,
+			"version":     updated.Version,
 		},
 	})
 }
 
+// checkoutVersionError mengirim response untuk versi checkout yang tidak cocok
+func checkoutVersionError(c *fiber.Ctx, status int, version int64) error {
+	message := "Checkout sudah diubah oleh request lain, muat ulang lalu coba lagi"
+	if status == http.StatusPreconditionFailed {
+		message = "Versi checkout tidak cocok dengan If-Match"
+	}
+	setETag(c, version)
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   message,
+	})
+}
+
 func GetAllCheckout(c *fiber.Ctx) error {
 	collection := config.GetCollection("checkout")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	cursor, err := collection.Find(ctx, bson.M{})
 	if err != nil {
@@ -294,25 +563,48 @@ func GetAllCheckout(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteCheckout handles the deletion of a checkout by checkoutID
+// DeleteCheckout menghapus checkout yang sudah selesai atau dibatalkan (khusus admin).
+// Checkout Pending ditolak agar stok dan kuota promosinya tidak hilang tanpa dikembalikan.
 func DeleteCheckout(c *fiber.Ctx) error {
 	// Mendapatkan checkoutID dari parameter URL
 	checkoutID := c.Params("checkout_id")
+	ctx := c.UserContext()
 
-	// Mengambil koleksi checkout
-	collection := config.GetCollection("checkout")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	checkout, err := repository.FindCheckoutByID(ctx, checkoutID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Checkout tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan checkout",
+		})
+	}
+	if checkout.Status == models.CheckoutStatusPending {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Checkout masih Pending, batalkan checkout terlebih dahulu",
+		})
+	}
+	if checkout.Status == models.CheckoutStatusCancelled && checkout.StockReleasedAt == nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Stok checkout yang dibatalkan belum dikembalikan, coba lagi nanti",
+		})
+	}
 
-	// Menghapus checkout berdasarkan checkoutID
-	_, err := collection.DeleteOne(ctx, bson.M{"checkout_id": checkoutID})
+	// Filter status diulang saat menghapus agar checkout yang berubah di antaranya tidak ikut terhapus
+	err = repository.DeleteFinishedCheckout(ctx, checkoutID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Checkout sudah diubah oleh request lain, muat ulang lalu coba lagi",
+		})
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"success": false,
-				"error":   "Checkout tidak ditemukan",
-			})
-		}
 		log.Printf("Error deleting checkout for checkoutID %s: %v\n", checkoutID, err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
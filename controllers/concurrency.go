@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// entityTag membentuk ETag dari versi dokumen, misalnya "3"
+func entityTag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag mengirim versi dokumen sebagai header ETag agar bisa dikirim balik lewat If-Match
+func setETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, entityTag(version))
+}
+
+// checkVersion membandingkan If-Match dan field version di body dengan versi dokumen saat ini.
+// Mengembalikan 0 jika boleh diubah, 412 jika If-Match tidak cocok, atau 409 jika version
+// di body sudah usang. Request tanpa keduanya tetap diterima seperti sebelumnya.
+func checkVersion(c *fiber.Ctx, bodyVersion *int64, current int64) int {
+	if header := c.Get(fiber.HeaderIfMatch); header != "" && !ifMatches(header, current) {
+		return http.StatusPreconditionFailed
+	}
+	if bodyVersion != nil && *bodyVersion != current {
+		return http.StatusConflict
+	}
+	return 0
+}
+
+// ifMatches memeriksa daftar ETag di header If-Match; tag lemah (W/) dibandingkan tanpa awalannya
+func ifMatches(header string, version int64) bool {
+	want := entityTag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == want {
+			return true
+		}
+	}
+	return false
+}
@@ -11,8 +11,8 @@ func GetProductCount(c *fiber.Ctx) error {
 	// Menggunakan GetCollection untuk mengambil koleksi produk
 	collection := config.GetCollection("products")
 
-	// Menghitung jumlah produk
-	count, err := collection.CountDocuments(c.Context(), bson.M{})
+	// Menghitung jumlah produk; produk yang dihapus tidak dihitung
+	count, err := collection.CountDocuments(c.UserContext(), bson.M{"deleted_at": nil})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Gagal menghitung jumlah produk",
@@ -31,7 +31,7 @@ func GetUserCount(c *fiber.Ctx) error {
 	collection := config.GetCollection("users")
 
 	// Menghitung jumlah pengguna
-	count, err := collection.CountDocuments(c.Context(), bson.M{})
+	count, err := collection.CountDocuments(c.UserContext(), bson.M{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Gagal menghitung jumlah pengguna",
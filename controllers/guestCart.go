@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"be-stepup/cartservice"
+	"be-stepup/config"
+	"be-stepup/middleware"
+	"be-stepup/models"
+	"be-stepup/package/token"
+	"be-stepup/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
+	"time"
+)
+
+// guestCartPrefix membedakan keranjang tamu dari keranjang user pada field user_id
+const guestCartPrefix = "guest:"
+
+// cartOwner mengembalikan pemilik keranjang untuk request: userID jika login, atau "guest:<id>" untuk tamu
+func cartOwner(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(string); ok {
+		return userID
+	}
+	return guestCartPrefix + c.Locals("guestID").(string)
+}
+
+// cartUserName mengembalikan nama user pemilik keranjang; tamu tidak memiliki nama
+func cartUserName(ctx context.Context, ownerID string) (string, error) {
+	if strings.HasPrefix(ownerID, guestCartPrefix) {
+		return "", nil
+	}
+	user, err := repository.FindUserByID(ctx, ownerID)
+	if err != nil {
+		return "", err
+	}
+	return user.Name, nil
+}
+
+// mergeGuestCartOnLogin menggabungkan keranjang tamu dari request ke keranjang user setelah
+// Login/Register berhasil. Kegagalan hanya dicatat agar tidak menggagalkan login.
+func mergeGuestCartOnLogin(c *fiber.Ctx, userID string) []cartservice.Warning {
+	cartToken := middleware.GuestCartToken(c)
+	if cartToken == "" {
+		return nil
+	}
+	guestID, err := token.ParseCartToken(cartToken)
+	if err != nil {
+		middleware.ClearGuestCartToken(c)
+		return nil
+	}
+
+	warnings, err := mergeGuestCart(c.UserContext(), guestCartPrefix+guestID, userID)
+	if err != nil {
+		log.Printf("Error merging guest cart for userID %s: %v\n", userID, err)
+		return nil
+	}
+	middleware.ClearGuestCartToken(c)
+	return warnings
+}
+
+// mergeGuestCart memindahkan item keranjang tamu ke keranjang user lalu menghapus keranjang tamu
+func mergeGuestCart(ctx context.Context, guestOwnerID, userID string) ([]cartservice.Warning, error) {
+	collection := config.GetCollection("cart")
+
+	var guestCart models.Cart
+	err := collection.FindOne(ctx, bson.M{"user_id": guestOwnerID}).Decode(&guestCart)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var userCart models.Cart
+	err = collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&userCart)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Jumlah dijumlahkan lalu dibatasi stok dan batas pembelian terbaru
+	productIDs := make([]string, 0, len(guestCart.Items))
+	for _, item := range guestCart.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := repository.FindProductsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	items, warnings := cartService.Merge(userCart.Items, guestCart.Items, availableProducts(products, time.Now()))
+
+	if len(items) > 0 {
+		now := time.Now()
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"user_id": userID},
+			bson.M{
+				"$set": bson.M{
+					"items":       items,
+					"user_name":   user.Name,
+					"modified_at": now,
+				},
+				"$setOnInsert": bson.M{
+					"cart_id":    generateUniqueID(),
+					"created_at": now,
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repository.DeleteCartByUserID(ctx, guestOwnerID); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+)
+
+// anonymousActor dicatat untuk perubahan dari rute yang tidak memerlukan login
+const anonymousActor = "anonymous"
+
+// requestActor mengembalikan userID pelaku request untuk dicatat di ledger
+func requestActor(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		return userID
+	}
+	return anonymousActor
+}
+
+// AdminListInventoryMovements mengembalikan ledger pergerakan stok dengan filter
+// ?product_id=, ?type=, dan ?reference_id= (khusus admin)
+func AdminListInventoryMovements(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	filter := bson.M{}
+	if productID := c.Query("product_id"); productID != "" {
+		filter["product_id"] = productID
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		filter["type"] = movementType
+	}
+	if referenceID := c.Query("reference_id"); referenceID != "" {
+		filter["reference_id"] = referenceID
+	}
+
+	movements, total, err := repository.ListInventoryMovements(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing inventory movements: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan pergerakan stok",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       movements,
+		"pagination": page,
+	})
+}
+
+// AdminAdjustInventory mencatat barang masuk, retur, atau koreksi stok manual (khusus admin)
+func AdminAdjustInventory(c *fiber.Ctx) error {
+	var req models.InventoryAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+	// Barang masuk dan retur selalu menambah stok; hanya koreksi yang boleh mengurangi
+	if req.Type != models.MovementAdjustment && req.Delta < 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Delta untuk restock dan retur harus positif",
+		})
+	}
+
+	ctx := c.UserContext()
+	if _, err := repository.FindProductByProductID(ctx, req.ProductID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Produk tidak ditemukan",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+	}
+
+	movement, err := productservice.AdjustStock(ctx, models.InventoryMovement{
+		ProductID:   req.ProductID,
+		Type:        req.Type,
+		Delta:       req.Delta,
+		Actor:       requestActor(c),
+		ReferenceID: req.ReferenceID,
+		Note:        req.Note,
+	})
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Stok tidak boleh menjadi negatif",
+		})
+	}
+	if err != nil {
+		log.Printf("Error adjusting stock for productID %s: %v\n", req.ProductID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyesuaikan stok",
+		})
+	}
+
+	raiseLowStockAlert(ctx, movement)
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Stok berhasil disesuaikan",
+		"data":    movement,
+	})
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"be-stepup/jobs"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"net/http"
+)
+
+// GetJobStatus mengembalikan status job latar belakang: replika pemegang lease, run terakhir,
+// dan hasilnya (khusus admin)
+func GetJobStatus(c *fiber.Ctx) error {
+	states, err := repository.ListJobStates(c.UserContext())
+	if err != nil {
+		log.Printf("Error listing job states: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan status job",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"instance_id": jobs.InstanceID(),
+		"data":        states,
+	})
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Rentang hari bawaan dan maksimum untuk menghitung kecepatan penjualan
+const (
+	defaultVelocityDays = 30
+	maxVelocityDays     = 365
+)
+
+// lowStockEntry adalah produk di bawah batas stok menipis beserta kecepatan penjualannya
+type lowStockEntry struct {
+	ProductID      string   `json:"product_id"`
+	ProductCode    string   `json:"product_code"`
+	ProductName    string   `json:"product_name"`
+	Stock          int      `json:"stock"`
+	Threshold      int      `json:"threshold"`
+	UnitsSold      int      `json:"units_sold"`
+	VelocityPerDay float64  `json:"velocity_per_day"`
+	DaysOfStock    *float64 `json:"days_of_stock"` // Perkiraan hari sampai stok habis; null jika tidak ada penjualan
+}
+
+// raiseLowStockAlert menyimpan peringatan dan mengirim notifikasi ke semua admin jika pergerakan
+// stok membuat produk turun melewati batas stok menipis. Kegagalan hanya dicatat agar tidak
+// menggagalkan transaksi yang sudah tersimpan.
+func raiseLowStockAlert(ctx context.Context, movement models.InventoryMovement) {
+	if movement.Delta >= 0 {
+		return
+	}
+	product, err := repository.FindProductByProductID(ctx, movement.ProductID)
+	if err != nil {
+		log.Printf("Error loading productID %s for low stock check: %v\n", movement.ProductID, err)
+		return
+	}
+	if !movement.CrossesBelow(product.LowStockThreshold) {
+		return
+	}
+
+	alert := models.LowStockAlert{
+		ProductID:   product.ProductID,
+		ProductCode: product.Code,
+		ProductName: product.Name,
+		Threshold:   product.LowStockThreshold,
+		Stock:       movement.ResultingStock,
+		MovementID:  movement.MovementID,
+		Actor:       movement.Actor,
+		ReferenceID: movement.ReferenceID,
+	}
+	if err := repository.InsertLowStockAlert(ctx, &alert); err != nil {
+		log.Printf("Error saving low stock alert for productID %s: %v\n", product.ProductID, err)
+		return
+	}
+
+	admins, err := repository.FindActiveUsersByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		log.Printf("Error loading admins for low stock alert %s: %v\n", alert.AlertID, err)
+		return
+	}
+	for _, admin := range admins {
+		_, err := repository.EnqueueNotification(ctx, models.Notification{
+			UserID:  admin.UserID,
+			Type:    models.NotificationLowStock,
+			Title:   "Stok menipis: " + product.Name,
+			Message: fmt.Sprintf("Stok %s (%s) tinggal %d, batas peringatan %d.", product.Name, product.Code, alert.Stock, alert.Threshold),
+			Data: map[string]interface{}{
+				"alert_id":   alert.AlertID,
+				"product_id": product.ProductID,
+				"stock":      alert.Stock,
+				"threshold":  alert.Threshold,
+			},
+		})
+		if err != nil {
+			log.Printf("Error enqueueing low stock notification for admin %s: %v\n", admin.UserID, err)
+		}
+	}
+}
+
+// AdminListLowStockProducts mengembalikan produk yang stoknya mencapai batas stok menipis,
+// diurutkan dari yang paling cepat terjual dalam ?days= hari terakhir (bawaan 30, khusus admin)
+func AdminListLowStockProducts(c *fiber.Ctx) error {
+	days := c.QueryInt("days", defaultVelocityDays)
+	if days < 1 || days > maxVelocityDays {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Parameter days harus antara 1 dan %d", maxVelocityDays),
+		})
+	}
+
+	ctx := c.UserContext()
+	products, err := repository.FindLowStockProducts(ctx)
+	if err != nil {
+		log.Printf("Error finding low stock products: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk dengan stok menipis",
+		})
+	}
+
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+	sales, err := repository.SalesByProduct(ctx, productIDs, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Error aggregating product sales: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung penjualan produk",
+		})
+	}
+
+	entries := make([]lowStockEntry, 0, len(products))
+	for _, product := range products {
+		units := sales[product.ProductID]
+		velocity := float64(units) / float64(days)
+		entry := lowStockEntry{
+			ProductID:      product.ProductID,
+			ProductCode:    product.Code,
+			ProductName:    product.Name,
+			Stock:          product.Stock,
+			Threshold:      product.LowStockThreshold,
+			UnitsSold:      units,
+			VelocityPerDay: math.Round(velocity*100) / 100,
+		}
+		if velocity > 0 {
+			daysOfStock := math.Round(float64(product.Stock)/velocity*10) / 10
+			entry.DaysOfStock = &daysOfStock
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].UnitsSold != entries[j].UnitsSold {
+			return entries[i].UnitsSold > entries[j].UnitsSold
+		}
+		return entries[i].Stock < entries[j].Stock
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"days":    days,
+		"data":    entries,
+	})
+}
+
+// AdminListLowStockAlerts mengembalikan riwayat peringatan stok menipis, bisa difilter ?product_id= (khusus admin)
+func AdminListLowStockAlerts(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	filter := bson.M{}
+	if productID := c.Query("product_id"); productID != "" {
+		filter["product_id"] = productID
+	}
+
+	alerts, total, err := repository.ListLowStockAlerts(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing low stock alerts: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan peringatan stok",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       alerts,
+		"pagination": page,
+	})
+}
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// avatarDir adalah folder penyimpanan foto profil yang dilayani di /avatars
+const avatarDir = "./avatars"
+
+// maxAvatarSize membatasi ukuran foto profil (2 MB)
+const maxAvatarSize = 2 << 20
+
+// GetMe mengembalikan profil user yang sedang login
+func GetMe(c *fiber.Ctx) error {
+	user, err := repository.FindUserByID(c.UserContext(), c.Locals("userID").(string))
+	if err != nil {
+		return currentUserError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+	})
+}
+
+// UpdateMe mengubah nama dan nomor telepon user yang sedang login
+func UpdateMe(c *fiber.Ctx) error {
+	var req models.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	return updateMe(c, bson.M{"name": req.Name, "phone_number": req.PhoneNumber}, "Profil berhasil diperbarui")
+}
+
+// ChangeMyPassword mengganti password setelah password lama diverifikasi
+func ChangeMyPassword(c *fiber.Ctx) error {
+	var req models.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Password saat ini salah",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memproses password",
+		})
+	}
+
+	if err := repository.UpdateUserByID(ctx, userID, bson.M{"password": string(hashedPassword)}); err != nil {
+		log.Printf("Error changing password for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mengganti password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password berhasil diganti",
+	})
+}
+
+// UploadMyAvatar menyimpan foto profil dari form field "avatar"
+func UploadMyAvatar(c *fiber.Ctx) error {
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Foto profil tidak ditemukan atau tidak valid",
+		})
+	}
+
+	// Validasi ekstensi dan ukuran file (hanya PNG, JPEG, JPG yang diperbolehkan)
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".png" && ext != ".jpeg" && ext != ".jpg" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Tipe file tidak valid. Hanya file PNG, JPEG, atau JPG yang diperbolehkan",
+		})
+	}
+	if file.Size > maxAvatarSize {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Ukuran foto profil maksimal 2 MB",
+		})
+	}
+
+	if err := os.MkdirAll(avatarDir, os.ModePerm); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat direktori avatars",
+		})
+	}
+
+	// Nama file unik agar cache browser tidak menampilkan foto lama
+	userID := c.Locals("userID").(string)
+	fileName := fmt.Sprintf("%s-%s%s", userID, uuid.New().String()[:8], ext)
+	if err := c.SaveFile(file, filepath.Join(avatarDir, fileName)); err != nil {
+		log.Println("Error saving avatar:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyimpan foto profil",
+		})
+	}
+
+	user, err := repository.FindUserByID(c.UserContext(), userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+	removeAvatarFile(user.AvatarURL)
+
+	avatarURL := fmt.Sprintf("http://localhost:3000/%s", path.Join("avatars", fileName))
+	return updateMe(c, bson.M{"avatar_url": avatarURL}, "Foto profil berhasil diperbarui")
+}
+
+// DeleteMe menghapus akun user yang sedang login. Riwayat checkout dan pesanan tetap
+// disimpan untuk laporan, tetapi nama, alamat, dan nomor telepon di dalamnya dihapus.
+func DeleteMe(c *fiber.Ctx) error {
+	var req models.DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Password salah",
+		})
+	}
+
+	// Anonimisasi dilakukan sebelum user dihapus sehingga permintaan bisa diulang jika gagal di tengah
+	if _, err := repository.AnonymizeCheckoutsByUser(ctx, userID); err != nil {
+		log.Printf("Error anonymizing checkouts for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus akun",
+		})
+	}
+	if _, err := repository.AnonymizeOrdersByUser(ctx, userID); err != nil {
+		log.Printf("Error anonymizing orders for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus akun",
+		})
+	}
+	if _, err := repository.AnonymizeReviewsByUser(ctx, userID); err != nil {
+		log.Printf("Error anonymizing reviews for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus akun",
+		})
+	}
+	if err := repository.DeleteCartByUserID(ctx, userID); err != nil {
+		log.Printf("Error deleting cart for userID %s: %v\n", userID, err)
+	}
+	if err := repository.DeleteWishlistByUser(ctx, userID); err != nil {
+		log.Printf("Error deleting wishlist for userID %s: %v\n", userID, err)
+	}
+	if err := repository.DeleteUserByID(ctx, userID); err != nil {
+		log.Printf("Error deleting userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus akun",
+		})
+	}
+	removeAvatarFile(user.AvatarURL)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Akun berhasil dihapus",
+	})
+}
+
+// updateMe menerapkan perubahan pada user yang sedang login lalu mengembalikan profil terbaru
+func updateMe(c *fiber.Ctx, fields bson.M, message string) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+
+	if err := repository.UpdateUserByID(ctx, userID, fields); err != nil {
+		log.Printf("Error updating profile for userID %s: %v\n", userID, err)
+		return currentUserError(c, err)
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    user,
+	})
+}
+
+// currentUserError memetakan kegagalan membaca user yang sedang login ke response
+func currentUserError(c *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "User tidak ditemukan",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Gagal mendapatkan user",
+	})
+}
+
+// removeAvatarFile menghapus file foto profil lama; kegagalan hanya dicatat
+func removeAvatarFile(avatarURL string) {
+	if avatarURL == "" {
+		return
+	}
+	name := path.Base(avatarURL)
+	if err := os.Remove(filepath.Join(avatarDir, name)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing avatar %s: %v\n", name, err)
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pagination menyimpan parameter ?page= dan ?limit= yang sudah dinormalisasi
+type pagination struct {
+	Page  int64 `json:"page"`
+	Limit int64 `json:"limit"`
+	Total int64 `json:"total"`
+}
+
+// parsePagination membaca ?page= dan ?limit= dengan nilai bawaan page 1 dan limit 20
+func parsePagination(c *fiber.Ctx) pagination {
+	page := int64(c.QueryInt("page", 1))
+	if page < 1 {
+		page = 1
+	}
+	limit := int64(c.QueryInt("limit", defaultPageLimit))
+	if limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return pagination{Page: page, Limit: limit}
+}
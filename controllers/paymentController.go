@@ -1,9 +1,10 @@
 package controllers
 
 import (
+	"be-stepup/audit"
 	"be-stepup/config"
 	"be-stepup/models"
-	"context"
+	"be-stepup/repository"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -36,6 +37,29 @@ func SavePayment(c *fiber.Ctx) error {
 		})
 	}
 
+	// Bukti pembayaran hanya diterima untuk checkout Pending yang belum melewati batas waktu
+	var checkout models.Checkout
+	err := config.GetCollection("checkout").FindOne(c.UserContext(), bson.M{"checkout_id": checkoutID}).Decode(&checkout)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Checkout tidak ditemukan",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan checkout",
+		})
+	}
+	if checkout.Status == models.CheckoutStatusCancelled ||
+		(checkout.Status == models.CheckoutStatusPending && !checkout.PaymentDeadline.IsZero() && time.Now().After(checkout.PaymentDeadline)) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Batas waktu pembayaran checkout sudah lewat",
+		})
+	}
+
 	// Mengambil file gambar bukti pembayaran
 	file, err := c.FormFile("payment_image")
 	if err != nil {
@@ -85,15 +109,14 @@ func SavePayment(c *fiber.Ctx) error {
 		CheckoutID:    checkoutID,
 		UserID:        c.Locals("userID").(string), // Mendapatkan userID dari token
 		PaymentImage:  imageURL,                    // URL publik
-		PaymentStatus: "Pending",                   // Status awal adalah Pending
+		PaymentStatus: models.PaymentStatusPending, // Status awal adalah Pending
 		CreatedAt:     time.Now(),
 		ModifiedAt:    time.Now(),
 	}
 
 	// Menyimpan data pembayaran ke dalam koleksi `payment`
 	collection := config.GetCollection("payment")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	_, err = collection.InsertOne(ctx, payment)
 	if err != nil {
@@ -118,8 +141,7 @@ func GetPaymentByCheckoutID(c *fiber.Ctx) error {
 
 	// Mengambil koleksi `payment`
 	collection := config.GetCollection("payment")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	// Mencari pembayaran berdasarkan checkoutID
 	var payment models.Payment
@@ -137,6 +159,7 @@ func GetPaymentByCheckoutID(c *fiber.Ctx) error {
 		})
 	}
 
+	setETag(c, payment.Version)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    payment,
@@ -147,7 +170,8 @@ func GetPaymentByCheckoutID(c *fiber.Ctx) error {
 func UpdatePaymentStatus(c *fiber.Ctx) error {
 	// Parse request body untuk mendapatkan status pembayaran
 	var updateRequest struct {
-		Status string `json:"status" validate:"required"`
+		Status  string `json:"status" validate:"required,oneof=Pending Verified Rejected"`
+		Version *int64 `json:"version"` // Alternatif header If-Match
 	}
 
 	if err := c.BodyParser(&updateRequest); err != nil {
@@ -156,26 +180,41 @@ func UpdatePaymentStatus(c *fiber.Ctx) error {
 			"error":   "Body request tidak valid",
 		})
 	}
+	if err := validate.Struct(updateRequest); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
 
 	// Mendapatkan paymentID dari parameter URL
 	paymentID := c.Params("payment_id")
+	ctx := c.UserContext()
 
-	// Mengambil koleksi `payment`
-	collection := config.GetCollection("payment")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	payment, err := repository.FindPaymentByID(ctx, paymentID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Pembayaran tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan pembayaran",
+		})
+	}
+
+	// Dua admin yang memverifikasi pembayaran yang sama tidak boleh saling menimpa
+	if status := checkVersion(c, updateRequest.Version, payment.Version); status != 0 {
+		return paymentVersionError(c, status, payment.Version)
+	}
 
 	// Memperbarui status pembayaran
-	_, err := collection.UpdateOne(
-		ctx,
-		bson.M{"payment_id": paymentID},
-		bson.M{
-			"$set": bson.M{
-				"payment_status": updateRequest.Status,
-				"modified_at":    time.Now(),
-			},
-		},
-	)
+	updated, err := repository.UpdatePaymentStatus(ctx, paymentID, payment.Version, updateRequest.Status, time.Now())
+	if err == mongo.ErrNoDocuments {
+		return paymentVersionError(c, http.StatusConflict, payment.Version)
+	}
 	if err != nil {
 		log.Printf("Error updating payment status for paymentID %s: %v\n", paymentID, err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -184,22 +223,40 @@ func UpdatePaymentStatus(c *fiber.Ctx) error {
 		})
 	}
 
+	audit.Detail(c, "previous_status", payment.PaymentStatus)
+	audit.Detail(c, "status", updated.PaymentStatus)
+	audit.Detail(c, "checkout_id", payment.CheckoutID)
+
+	setETag(c, updated.Version)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Status pembayaran berhasil diperbarui",
 		"data": fiber.Map{
 			"payment_id": paymentID,
-			"status":     updateRequest.Status,
+			"status":     updated.PaymentStatus,
+			"version":    updated.Version,
 		},
 	})
 }
 
+// paymentVersionError mengirim response untuk versi pembayaran yang tidak cocok
+func paymentVersionError(c *fiber.Ctx, status int, version int64) error {
+	message := "Pembayaran sudah diubah oleh request lain, muat ulang lalu coba lagi"
+	if status == http.StatusPreconditionFailed {
+		message = "Versi pembayaran tidak cocok dengan If-Match"
+	}
+	setETag(c, version)
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   message,
+	})
+}
+
 // GetAllPayments handles getting all payments
 func GetAllPayments(c *fiber.Ctx) error {
 	// Mengambil koleksi `payment`
 	collection := config.GetCollection("payment")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	// Mencari semua pembayaran
 	cursor, err := collection.Find(ctx, bson.M{})
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"be-stepup/productservice"
+	"context"
+	"log"
+	"time"
+)
+
+// rollbackTimeout membatasi pengembalian stok dan kuota promosi ketika checkout gagal dibuat
+const rollbackTimeout = 10 * time.Second
+
+// rollbackContext melepas rollback dari deadline dan pembatalan request: request yang gagal
+// karena timeout atau klien yang memutus koneksi tetap harus mengembalikan reservasinya
+func rollbackContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+}
+
+// priceChange melaporkan produk yang harganya berubah sejak ditambahkan ke keranjang
+type priceChange struct {
+	ProductID   string      `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	OldPrice    money.Money `json:"old_price"`
+	NewPrice    money.Money `json:"new_price"`
+}
+
+// releaseStock mengembalikan stok item yang sudah terlanjur dikurangi ketika checkout gagal dibuat
+func releaseStock(ctx context.Context, items []models.CartItem, actor, checkoutID string) {
+	ctx, cancel := rollbackContext(ctx)
+	defer cancel()
+	if err := productservice.RestoreStock(ctx, items, actor, checkoutID, "checkout failed"); err != nil {
+		log.Printf("Error releasing stock for checkout %s: %v\n", checkoutID, err)
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"net/http"
+)
+
+// AdminListProductAudits mengembalikan riwayat perubahan semua produk (khusus admin).
+// Filter: ?product_id=, ?actor=, ?action=, ?field=, ?movement_type=, ?reference_id=, ?from= dan ?to= (RFC3339)
+func AdminListProductAudits(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if productID := c.Query("product_id"); productID != "" {
+		filter["product_id"] = productID
+	}
+	if actor := c.Query("actor"); actor != "" {
+		filter["actor"] = actor
+	}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if field := c.Query("field"); field != "" {
+		filter["changes.field"] = field
+	}
+	if movementType := c.Query("movement_type"); movementType != "" {
+		filter["movement_type"] = movementType
+	}
+	if referenceID := c.Query("reference_id"); referenceID != "" {
+		filter["reference_id"] = referenceID
+	}
+
+	createdAt, msg := createdAtRange(c)
+	if msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   msg,
+		})
+	}
+	if createdAt != nil {
+		filter["created_at"] = createdAt
+	}
+
+	return listProductAudits(c, filter)
+}
+
+// AdminGetProductAudits mengembalikan riwayat perubahan satu produk, termasuk yang terhapus (khusus admin)
+func AdminGetProductAudits(c *fiber.Ctx) error {
+	product, ok := findAdminProduct(c)
+	if !ok {
+		return nil
+	}
+	return listProductAudits(c, bson.M{"product_id": product.ProductID})
+}
+
+func listProductAudits(c *fiber.Ctx, filter bson.M) error {
+	page := parsePagination(c)
+
+	audits, total, err := repository.ListProductAudits(c.UserContext(), filter, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing product audits: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan riwayat produk",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       audits,
+		"pagination": page,
+	})
+}
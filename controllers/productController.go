@@ -3,23 +3,34 @@ package controllers
 import (
 	"be-stepup/config"
 	"be-stepup/models"
+	"be-stepup/package/money"
+	"be-stepup/package/slug"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Definisikan folder untuk menyimpan file upload
 const uploadDir = "uploads"
 
+// GetAllProducts mengembalikan produk yang tampil di katalog publik
 func GetAllProducts(c *fiber.Ctx) error {
 	var products []models.Product
 	collection := config.GetCollection("products")
-	ctx := c.Context()
+	ctx := c.UserContext()
 
-	cursor, err := collection.Find(ctx, bson.D{})
+	cursor, err := collection.Find(ctx, repository.AvailableProductFilter(time.Now()))
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching products"})
 	}
@@ -48,9 +59,13 @@ func GetProductByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID"})
 	}
 
+	// Draft, arsip, dan produk terhapus hanya bisa dilihat lewat endpoint admin
+	filter := repository.AvailableProductFilter(time.Now())
+	filter["_id"] = productID
+
 	collection := config.GetCollection("products")
 	var product models.Product
-	err = collection.FindOne(c.Context(), bson.M{"_id": productID}).Decode(&product)
+	err = collection.FindOne(c.UserContext(), filter).Decode(&product)
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
@@ -58,24 +73,33 @@ func GetProductByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching product"})
 	}
 
+	setETag(c, product.Version)
 	return c.JSON(product)
 }
 
 // CreateProduct creates a new product and saves it to the database
 func CreateProduct(c *fiber.Ctx) error {
-	var product models.Product
+	// Parsing data produk; ID, kode, versi, rating, dan deleted_at tidak bisa diisi dari request
+	var req models.ProductCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to parse request"})
+	}
 
 	// Generate unique ProductID
-	product.ProductID = "PROD-" + uuid.New().String()[:8]
-	product.Code = "SKU-" + uuid.New().String()[:8]
+	product := req.Product("PROD-"+uuid.New().String()[:8], "SKU-"+uuid.New().String()[:8])
 
-	// Parsing data produk
-	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to parse request"})
+	// Produk tanpa status langsung aktif seperti sebelum ada status produk
+	if product.Status == "" {
+		product.Status = models.ProductActive
+	}
+	status, msg := normalizeProductSchedule(product.Status, product.PublishAt, product.UnpublishAt, time.Now())
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
 	}
+	product.Status = status
 
 	// Validasi harga
-	if product.Price <= 0 {
+	if !product.Price.IsPositive() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Price must be greater than zero"})
 	}
 
@@ -84,6 +108,26 @@ func CreateProduct(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Stock cannot be negative"})
 	}
 
+	// Validasi berat kirim
+	if product.WeightGrams < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Weight cannot be negative"})
+	}
+
+	// Validasi batas pembelian per pesanan
+	if product.MaxOrderQuantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Max order quantity cannot be negative"})
+	}
+
+	// Validasi batas stok menipis
+	if product.LowStockThreshold < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Low stock threshold cannot be negative"})
+	}
+
+	// Validasi kategori dan merek
+	if err := resolveProductTaxonomy(c.UserContext(), &product); err != nil {
+		return productTaxonomyError(c, err)
+	}
+
 	// Penanganan gambar
 	file, err := c.FormFile("image")
 	if err == nil { // Gambar berhasil diterima
@@ -96,10 +140,8 @@ func CreateProduct(c *fiber.Ctx) error {
 		product.ImageURL = "" // Kosongkan jika gambar tidak diunggah
 	}
 
-	// Simpan produk ke database
-	collection := config.GetCollection("products")
-	_, err = collection.InsertOne(c.Context(), product)
-	if err != nil {
+	// Simpan produk ke database; stok awal dicatat sebagai barang masuk di ledger inventaris
+	if err := productservice.Create(c.UserContext(), product, requestActor(c)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create product"})
 	}
 
@@ -109,26 +151,46 @@ func CreateProduct(c *fiber.Ctx) error {
 		"productCode": product.Code,
 		"imageURL":    product.ImageURL,
 		"stock":       product.Stock,
+		"status":      product.Status,
 	})
 }
 
-// UpdateProduct updates an existing product
-func UpdateProduct(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	productID, err := primitive.ObjectIDFromHex(idParam)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID"})
-	}
+// productPatch berisi field produk yang boleh diubah; field nil tidak diubah
+type productPatch struct {
+	Name              *string      `json:"name"`
+	BrandID           *string      `json:"brand_id"`
+	Brand             *string      `json:"brand"`
+	CategoryID        *string      `json:"category_id"`
+	Category          *string      `json:"category"`
+	Color             *string      `json:"color"`
+	Price             *money.Money `json:"price"`
+	Stock             *int         `json:"stock"`
+	WeightGrams       *int         `json:"weight_grams"`
+	MaxOrderQuantity  *int         `json:"max_order_quantity"`
+	LowStockThreshold *int         `json:"low_stock_threshold"`
+	Description       *string      `json:"description"`
+	ImageURL          *string      `json:"image_url"`
+	Version           *int64       `json:"version"` // Alternatif header If-Match
+}
 
+// UpdateProduct replaces every editable field of a product
+func UpdateProduct(c *fiber.Ctx) error {
 	// Struktur untuk data yang akan di-update
 	var productData struct {
-		Name        string  `json:"name"`
-		Brand       string  `json:"brand"`
-		Category    string  `json:"category"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Description string  `json:"description"`
-		ImageURL    string  `json:"image_url"`
+		Name              string      `json:"name"`
+		BrandID           string      `json:"brand_id"`
+		Brand             string      `json:"brand"`
+		CategoryID        string      `json:"category_id"`
+		Category          string      `json:"category"`
+		Color             string      `json:"color"`
+		Price             money.Money `json:"price"`
+		Stock             int         `json:"stock"`
+		WeightGrams       int         `json:"weight_grams"`
+		MaxOrderQuantity  int         `json:"max_order_quantity"`
+		LowStockThreshold int         `json:"low_stock_threshold"`
+		Description       string      `json:"description"`
+		ImageURL          string      `json:"image_url"`
+		Version           *int64      `json:"version"`
 	}
 
 	// Parsing body request
@@ -136,51 +198,156 @@ func UpdateProduct(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to parse request"})
 	}
 
-	collection := config.GetCollection("products")
-	filter := bson.M{"_id": productID}
+	patch := productPatch{
+		Name:              &productData.Name,
+		BrandID:           &productData.BrandID,
+		Brand:             &productData.Brand,
+		CategoryID:        &productData.CategoryID,
+		Category:          &productData.Category,
+		Color:             &productData.Color,
+		Price:             &productData.Price,
+		Stock:             &productData.Stock,
+		WeightGrams:       &productData.WeightGrams,
+		MaxOrderQuantity:  &productData.MaxOrderQuantity,
+		LowStockThreshold: &productData.LowStockThreshold,
+		Description:       &productData.Description,
+		Version:           productData.Version,
+	}
+	// Jika ImageURL kosong, gunakan URL gambar lama
+	if productData.ImageURL != "" {
+		patch.ImageURL = &productData.ImageURL
+	}
+	return saveProductPatch(c, patch)
+}
 
-	// Mendapatkan data produk yang ada
-	var existingProduct models.Product
-	err = collection.FindOne(c.Context(), filter).Decode(&existingProduct)
+// PatchProduct updates only the fields present in the request body
+func PatchProduct(c *fiber.Ctx) error {
+	var patch productPatch
+	if err := c.BodyParser(&patch); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to parse request"})
+	}
+	return saveProductPatch(c, patch)
+}
+
+// saveProductPatch memvalidasi lalu menyimpan perubahan produk dengan pemeriksaan versi.
+// If-Match yang tidak cocok menghasilkan 412, version di body yang usang atau perubahan
+// bersamaan dari request lain menghasilkan 409.
+func saveProductPatch(c *fiber.Ctx, patch productPatch) error {
+	idParam := c.Params("id")
+	productID, err := primitive.ObjectIDFromHex(idParam)
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID"})
+	}
+
+	// Mendapatkan data produk yang ada
+	existingProduct, err := repository.FindProductByObjectID(c.UserContext(), productID)
+	if err != nil || existingProduct.DeletedAt != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
 	}
 
-	// Jika ImageURL kosong, gunakan URL gambar lama
-	if productData.ImageURL == "" {
-		productData.ImageURL = existingProduct.ImageURL
+	switch checkVersion(c, patch.Version, existingProduct.Version) {
+	case http.StatusPreconditionFailed:
+		setETag(c, existingProduct.Version)
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": "Product version does not match If-Match"})
+	case http.StatusConflict:
+		setETag(c, existingProduct.Version)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Product was modified by another request, reload and try again"})
 	}
 
 	// Validasi input
-	if productData.Price <= 0 {
+	if patch.Price != nil && !patch.Price.IsPositive() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Price must be greater than zero"})
 	}
-	if productData.Stock < 0 {
+	if patch.Stock != nil && *patch.Stock < 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Stock cannot be negative"})
 	}
+	if patch.WeightGrams != nil && *patch.WeightGrams < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Weight cannot be negative"})
+	}
+	if patch.MaxOrderQuantity != nil && *patch.MaxOrderQuantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Max order quantity cannot be negative"})
+	}
+	if patch.LowStockThreshold != nil && *patch.LowStockThreshold < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Low stock threshold cannot be negative"})
+	}
 
-	// Update produk di database
-	update := bson.M{
-		"$set": bson.M{
-			"name":        productData.Name,
-			"brand":       productData.Brand,
-			"category":    productData.Category,
-			"price":       productData.Price,
-			"stock":       productData.Stock,
-			"description": productData.Description,
-			"image_url":   productData.ImageURL, // Update image URL
-		},
+	set := bson.M{}
+	setString := func(field string, value *string) {
+		if value != nil {
+			set[field] = *value
+		}
+	}
+	setInt := func(field string, value *int) {
+		if value != nil {
+			set[field] = *value
+		}
+	}
+	setString("name", patch.Name)
+	setString("color", patch.Color)
+	setString("description", patch.Description)
+	setString("image_url", patch.ImageURL)
+	setInt("weight_grams", patch.WeightGrams)
+	setInt("max_order_quantity", patch.MaxOrderQuantity)
+	setInt("low_stock_threshold", patch.LowStockThreshold)
+	if patch.Price != nil {
+		set["price"] = *patch.Price
 	}
 
-	_, err = collection.UpdateOne(c.Context(), filter, update)
+	// Kategori dan merek hanya divalidasi ulang jika salah satu field-nya dikirim
+	if patch.CategoryID != nil || patch.Category != nil {
+		taxonomy := models.Product{CategoryID: deref(patch.CategoryID), Category: deref(patch.Category)}
+		if err := resolveProductTaxonomy(c.UserContext(), &taxonomy); err != nil {
+			return productTaxonomyError(c, err)
+		}
+		set["category_id"] = taxonomy.CategoryID
+		set["category"] = taxonomy.Category
+	}
+	if patch.BrandID != nil || patch.Brand != nil {
+		taxonomy := models.Product{BrandID: deref(patch.BrandID), Brand: deref(patch.Brand)}
+		if err := resolveProductTaxonomy(c.UserContext(), &taxonomy); err != nil {
+			return productTaxonomyError(c, err)
+		}
+		set["brand_id"] = taxonomy.BrandID
+		set["brand"] = taxonomy.Brand
+	}
+
+	// Update produk di database; versi tetap dinaikkan walaupun hanya stok yang dikirim
+	updated, err := productservice.Update(c.UserContext(), existingProduct, set, requestActor(c))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Product was modified by another request, reload and try again"})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update product"})
 	}
 
-	return c.JSON(fiber.Map{"message": "Product updated successfully"})
+	// Stok diganti lewat ledger agar selisihnya tercatat sebagai penyesuaian manual
+	if patch.Stock != nil {
+		movement, changed, err := productservice.SetStock(c.UserContext(), existingProduct.ProductID, *patch.Stock, models.InventoryMovement{
+			Type:  models.MovementAdjustment,
+			Actor: requestActor(c),
+			Note:  "product update",
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update product stock"})
+		}
+		if changed {
+			raiseLowStockAlert(c.UserContext(), movement)
+		}
+	}
+
+	setETag(c, updated.Version)
+	return c.JSON(fiber.Map{"message": "Product updated successfully", "version": updated.Version})
+}
+
+// deref mengembalikan nilai string atau string kosong untuk pointer nil
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
 }
 
-// DeleteProduct deletes a product from the database
+// DeleteProduct soft-deletes a product so historical checkouts keep their reference; it can be restored by an admin
 func DeleteProduct(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	productID, err := primitive.ObjectIDFromHex(idParam)
@@ -191,11 +358,28 @@ func DeleteProduct(c *fiber.Ctx) error {
 	collection := config.GetCollection("products")
 	filter := bson.M{"_id": productID}
 
-	_, err = collection.DeleteOne(c.Context(), filter)
+	var product models.Product
+	err = collection.FindOne(c.UserContext(), filter).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching product"})
+	}
+
+	err = productservice.Delete(c.UserContext(), product, requestActor(c))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete product"})
 	}
 
+	// Hapus produk dari semua keranjang agar tidak ada item yang menggantung
+	if _, err := repository.RemoveProductFromCarts(c.UserContext(), product.ProductID); err != nil {
+		log.Printf("Error removing productID %s from carts: %v\n", product.ProductID, err)
+	}
+
 	return c.JSON(fiber.Map{"message": "Product deleted successfully"})
 }
 
@@ -203,9 +387,12 @@ func DeleteProduct(c *fiber.Ctx) error {
 func GetProductByCode(c *fiber.Ctx) error {
 	code := c.Params("code")
 
+	filter := repository.AvailableProductFilter(time.Now())
+	filter["code"] = code
+
 	collection := config.GetCollection("products")
 	var product models.Product
-	err := collection.FindOne(c.Context(), bson.M{"code": code}).Decode(&product)
+	err := collection.FindOne(c.UserContext(), filter).Decode(&product)
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
@@ -213,6 +400,7 @@ func GetProductByCode(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching product"})
 	}
 
+	setETag(c, product.Version)
 	return c.JSON(product)
 }
 
@@ -238,3 +426,93 @@ func UploadImage(c *fiber.Ctx) error {
 	// Kembalikan URL sebagai respons
 	return c.JSON(fiber.Map{"image_url": fileURL})
 }
+
+var (
+	errCategoryNotFound = errors.New("category not found")
+	errBrandNotFound    = errors.New("brand not found")
+)
+
+// resolveProductTaxonomy memastikan kategori dan merek produk terdaftar lalu menyalin nama kanoniknya.
+// Referensi dicari dari category_id/brand_id, atau dari slug nama lama jika ID tidak dikirim.
+func resolveProductTaxonomy(ctx context.Context, product *models.Product) error {
+	if product.CategoryID != "" || strings.TrimSpace(product.Category) != "" {
+		var category models.Category
+		var err error
+		if product.CategoryID != "" {
+			category, err = repository.FindCategoryByID(ctx, product.CategoryID)
+		} else {
+			category, err = repository.FindCategoryBySlug(ctx, slug.Make(product.Category))
+		}
+		if err == mongo.ErrNoDocuments {
+			return errCategoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		product.CategoryID = category.CategoryID
+		product.Category = category.Name
+	}
+
+	if product.BrandID != "" || strings.TrimSpace(product.Brand) != "" {
+		var brand models.Brand
+		var err error
+		if product.BrandID != "" {
+			brand, err = repository.FindBrandByID(ctx, product.BrandID)
+		} else {
+			brand, err = repository.FindBrandBySlug(ctx, slug.Make(product.Brand))
+		}
+		if err == mongo.ErrNoDocuments {
+			return errBrandNotFound
+		}
+		if err != nil {
+			return err
+		}
+		product.BrandID = brand.BrandID
+		product.Brand = brand.Name
+	}
+	return nil
+}
+
+// productTaxonomyError mengirim response untuk kegagalan resolveProductTaxonomy
+func productTaxonomyError(c *fiber.Ctx, err error) error {
+	switch err {
+	case errCategoryNotFound:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Category not found"})
+	case errBrandNotFound:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Brand not found"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching category or brand"})
+}
+
+// normalizeProductSchedule memeriksa status dan jadwal tayang produk. Produk aktif dengan
+// publish_at di masa depan disimpan sebagai draft sampai jadwalnya tiba. Pesan kosong berarti valid.
+func normalizeProductSchedule(status string, publishAt, unpublishAt *time.Time, now time.Time) (string, string) {
+	switch status {
+	case models.ProductDraft, models.ProductActive, models.ProductArchived:
+	default:
+		return status, "Status must be one of draft, active, archived"
+	}
+	if unpublishAt != nil {
+		if !unpublishAt.After(now) {
+			return status, "Unpublish time must be in the future"
+		}
+		if publishAt != nil && !unpublishAt.After(*publishAt) {
+			return status, "Unpublish time must be after publish time"
+		}
+	}
+	if status == models.ProductActive && publishAt != nil && publishAt.After(now) {
+		status = models.ProductDraft
+	}
+	return status, ""
+}
+
+// availableProducts membuang produk yang tidak tampil di katalog publik, sehingga keranjang
+// dan wishlist memperlakukannya sama seperti produk yang sudah dihapus
+func availableProducts(products map[string]models.Product, now time.Time) map[string]models.Product {
+	for id, product := range products {
+		if !product.Available(now) {
+			delete(products, id)
+		}
+	}
+	return products
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/promotion"
+	"be-stepup/repository"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errVoucherNotFound  = errors.New("kode voucher tidak ditemukan")
+	errVoucherExhausted = errors.New("kuota voucher sudah habis")
+	errVoucherUserLimit = errors.New("batas pemakaian voucher untuk akun ini sudah tercapai")
+	errPromotionQuota   = errors.New("kuota promosi sudah habis, silakan coba lagi")
+)
+
+// isPromotionError bernilai true untuk error yang pesannya aman ditampilkan ke user
+func isPromotionError(err error) bool {
+	for _, target := range []error{
+		errVoucherNotFound, errVoucherExhausted, errVoucherUserLimit, errPromotionQuota,
+		promotion.ErrInactive, promotion.ErrMinSpendNotMet, promotion.ErrNoEligibleItems, promotion.ErrCurrencyMismatch,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVoucherCode menyamakan format kode voucher, disimpan dalam huruf besar
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// withinUsageLimits mengecek batas pemakaian global dan per user saat menghitung harga.
+// Pemeriksaan ini hanya perkiraan; batas ditegakkan secara atomik oleh reservePromotions.
+func withinUsageLimits(ctx context.Context, promo models.Promotion, userID string) (bool, error) {
+	if promo.UsageLimit > 0 && promo.UsedCount >= promo.UsageLimit {
+		return false, nil
+	}
+	if promo.UsageLimitPerUser > 0 {
+		used, err := repository.CountPromotionUsageByUser(ctx, promo.PromotionID, userID)
+		if err != nil {
+			return false, err
+		}
+		if used >= int64(promo.UsageLimitPerUser) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// applyPromotions memuat promosi otomatis dan voucher lalu menghitung diskon keranjang
+func applyPromotions(ctx context.Context, userID string, lines []promotion.Line, voucherCode string) (promotion.Result, error) {
+	now := time.Now()
+
+	candidates, err := repository.FindAutomaticPromotions(ctx, now)
+	if err != nil {
+		return promotion.Result{}, err
+	}
+	var automatic []models.Promotion
+	for _, promo := range candidates {
+		ok, err := withinUsageLimits(ctx, promo, userID)
+		if err != nil {
+			return promotion.Result{}, err
+		}
+		if ok {
+			automatic = append(automatic, promo)
+		}
+	}
+
+	var voucher *models.Promotion
+	if code := normalizeVoucherCode(voucherCode); code != "" {
+		promo, err := repository.FindPromotionByCode(ctx, code)
+		if err == mongo.ErrNoDocuments {
+			return promotion.Result{}, errVoucherNotFound
+		}
+		if err != nil {
+			return promotion.Result{}, err
+		}
+		if promo.UsageLimit > 0 && promo.UsedCount >= promo.UsageLimit {
+			return promotion.Result{}, errVoucherExhausted
+		}
+		ok, err := withinUsageLimits(ctx, promo, userID)
+		if err != nil {
+			return promotion.Result{}, err
+		}
+		if !ok {
+			return promotion.Result{}, errVoucherUserLimit
+		}
+		voucher = &promo
+	}
+
+	if hasCategoryScope(automatic, voucher) {
+		if lines, err = withCategoryPaths(ctx, lines); err != nil {
+			return promotion.Result{}, err
+		}
+	}
+	return promotion.Evaluate(lines, automatic, voucher, now)
+}
+
+// hasCategoryScope bernilai true jika ada promosi kategori sehingga pohon kategori perlu dimuat
+func hasCategoryScope(automatic []models.Promotion, voucher *models.Promotion) bool {
+	if voucher != nil && voucher.Scope == models.PromotionScopeCategory {
+		return true
+	}
+	for _, promo := range automatic {
+		if promo.Scope == models.PromotionScopeCategory {
+			return true
+		}
+	}
+	return false
+}
+
+// withCategoryPaths mengisi kategori induk setiap item agar promosi kategori induk ikut berlaku
+func withCategoryPaths(ctx context.Context, lines []promotion.Line) ([]promotion.Line, error) {
+	categories, err := repository.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths := promotion.CategoryPaths(categories)
+
+	withPaths := make([]promotion.Line, len(lines))
+	for i, line := range lines {
+		line.CategoryPath = paths[line.CategoryID]
+		withPaths[i] = line
+	}
+	return withPaths, nil
+}
+
+// reservePromotions memesan jatah pemakaian per user lalu menaikkan used_count setiap promosi
+// yang diterapkan pada checkoutID. Jika salah satu batas tercapai, semua reservasi dibatalkan.
+func reservePromotions(ctx context.Context, applied []models.AppliedDiscount, userID, checkoutID string) ([]string, error) {
+	var reserved []string
+	fail := func(err error) ([]string, error) {
+		releasePromotions(ctx, reserved, checkoutID)
+		return nil, err
+	}
+
+	for _, discount := range applied {
+		promo, err := repository.FindPromotionByID(ctx, discount.PromotionID)
+		if err == mongo.ErrNoDocuments {
+			return fail(errPromotionQuota)
+		}
+		if err != nil {
+			return fail(err)
+		}
+
+		// Jatah per user dipesan lebih dulu; batas global dicek setelahnya
+		ok, err := repository.ReserveUserPromotionUsage(ctx, models.PromotionUsage{
+			PromotionID: promo.PromotionID,
+			UserID:      userID,
+			CheckoutID:  checkoutID,
+			CreatedAt:   time.Now(),
+		}, promo.UsageLimitPerUser)
+		if err != nil {
+			return fail(err)
+		}
+		if !ok {
+			return fail(errVoucherUserLimit)
+		}
+
+		ok, err = repository.ReservePromotionUsage(ctx, promo.PromotionID)
+		if err != nil {
+			return fail(err)
+		}
+		if !ok {
+			return fail(errPromotionQuota)
+		}
+		reserved = append(reserved, promo.PromotionID)
+	}
+	return reserved, nil
+}
+
+// releasePromotions mengembalikan kuota promosi yang sudah dipesan beserta jatah per user
+// milik checkoutID
+func releasePromotions(ctx context.Context, promotionIDs []string, checkoutID string) {
+	ctx, cancel := rollbackContext(ctx)
+	defer cancel()
+	for _, id := range promotionIDs {
+		if err := repository.ReleasePromotionUsage(ctx, id); err != nil {
+			log.Printf("Error releasing promotion usage %s: %v\n", id, err)
+		}
+	}
+	if err := repository.DeletePromotionUsageByCheckout(ctx, checkoutID); err != nil {
+		log.Printf("Error releasing promotion usages of checkout %s: %v\n", checkoutID, err)
+	}
+}
+
+// validatePromotion memeriksa aturan yang tidak bisa diekspresikan dengan tag validator
+func validatePromotion(promo models.Promotion) string {
+	switch promo.Type {
+	case models.PromotionPercentage:
+		if promo.Percentage <= 0 {
+			return "Persentase diskon harus lebih dari nol"
+		}
+	case models.PromotionFixedAmount:
+		if !promo.Amount.IsPositive() {
+			return "Nominal diskon harus lebih dari nol"
+		}
+	}
+	switch promo.Scope {
+	case models.PromotionScopeProduct:
+		if len(promo.ProductIDs) == 0 {
+			return "product_ids wajib diisi untuk promosi produk"
+		}
+	case models.PromotionScopeCategory:
+		if len(promo.CategoryIDs) == 0 {
+			return "category_ids wajib diisi untuk promosi kategori"
+		}
+	}
+	if !promo.EndsAt.IsZero() && !promo.EndsAt.After(promo.StartsAt) {
+		return "ends_at harus setelah starts_at"
+	}
+	return ""
+}
+
+// parsePromotion membaca dan memvalidasi body promosi; pesan kosong berarti valid
+func parsePromotion(c *fiber.Ctx) (models.Promotion, string) {
+	var promo models.Promotion
+	if err := c.BodyParser(&promo); err != nil {
+		return promo, "Body request tidak valid"
+	}
+	promo.Code = normalizeVoucherCode(promo.Code)
+
+	if err := validate.Struct(promo); err != nil {
+		return promo, "Data tidak valid: " + err.Error()
+	}
+	if msg := validatePromotion(promo); msg != "" {
+		return promo, msg
+	}
+
+	// Kategori disimpan sebagai category_id agar tetap cocok walaupun kategori diganti namanya
+	for _, categoryID := range promo.CategoryIDs {
+		_, err := repository.FindCategoryByID(c.UserContext(), categoryID)
+		if err == mongo.ErrNoDocuments {
+			return promo, "Kategori " + categoryID + " tidak ditemukan"
+		}
+		if err != nil {
+			return promo, "Gagal memeriksa kategori"
+		}
+	}
+	return promo, ""
+}
+
+// GetAllPromotions mengembalikan semua promosi dengan paginasi (admin)
+func GetAllPromotions(c *fiber.Ctx) error {
+	page := parsePagination(c)
+	promos, total, err := repository.ListPromotions(c.UserContext(), page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing promotions: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan promosi",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       promos,
+		"pagination": page,
+	})
+}
+
+// GetPromotionByID mengembalikan satu promosi (admin)
+func GetPromotionByID(c *fiber.Ctx) error {
+	promo, err := repository.FindPromotionByID(c.UserContext(), c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Promosi tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan promosi",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    promo,
+	})
+}
+
+// CreatePromotion membuat voucher atau promosi otomatis baru (admin)
+func CreatePromotion(c *fiber.Ctx) error {
+	promo, msg := parsePromotion(c)
+	if msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   msg,
+		})
+	}
+
+	promo.PromotionID = "PROMO-" + uuid.New().String()[:8]
+	promo.UsedCount = 0
+	promo.CreatedAt = time.Now()
+	promo.ModifiedAt = time.Now()
+
+	err := repository.InsertPromotion(c.UserContext(), promo)
+	if mongo.IsDuplicateKeyError(err) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kode voucher sudah digunakan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating promotion: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal membuat promosi",
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Promosi berhasil dibuat",
+		"data":    promo,
+	})
+}
+
+// UpdatePromotion memperbarui promosi (admin). Kode voucher tidak dapat diubah.
+func UpdatePromotion(c *fiber.Ctx) error {
+	promo, msg := parsePromotion(c)
+	if msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   msg,
+		})
+	}
+
+	promo.PromotionID = c.Params("id")
+	promo.ModifiedAt = time.Now()
+
+	err := repository.ReplacePromotion(c.UserContext(), promo)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Promosi tidak ditemukan",
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating promotion %s: %v\n", promo.PromotionID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui promosi",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Promosi berhasil diperbarui",
+	})
+}
+
+// DeletePromotion menghapus promosi (admin)
+func DeletePromotion(c *fiber.Ctx) error {
+	err := repository.DeletePromotion(c.UserContext(), c.Params("id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Promosi tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus promosi",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Promosi berhasil dihapus",
+	})
+}
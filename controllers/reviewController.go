@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// reviewPhotoDir adalah folder foto ulasan yang dilayani di /review-photos
+const reviewPhotoDir = "./review-photos"
+
+// maxReviewPhotos dan maxReviewPhotoSize membatasi foto yang dilampirkan pada satu ulasan
+const (
+	maxReviewPhotos    = 3
+	maxReviewPhotoSize = 2 << 20
+)
+
+// reviewSorts memetakan parameter ?sort= ke urutan query
+var reviewSorts = map[string]bson.D{
+	"newest":  {{Key: "created_at", Value: -1}},
+	"oldest":  {{Key: "created_at", Value: 1}},
+	"highest": {{Key: "rating", Value: -1}, {Key: "created_at", Value: -1}},
+	"lowest":  {{Key: "rating", Value: 1}, {Key: "created_at", Value: -1}},
+}
+
+// ListProductReviews mengembalikan ulasan yang disetujui untuk sebuah produk dengan paginasi.
+// Urutan dipilih dengan ?sort=newest|oldest|highest|lowest (bawaan newest).
+func ListProductReviews(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	page := parsePagination(c)
+
+	sort, ok := reviewSorts[c.Query("sort", "newest")]
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parameter sort tidak valid",
+		})
+	}
+
+	product, err := repository.FindProductByProductID(ctx, c.Params("product_id"))
+	if err != nil {
+		return reviewProductError(c, err)
+	}
+
+	filter := bson.M{"product_id": product.ProductID, "status": models.ReviewApproved}
+	reviews, total, err := repository.ListReviews(ctx, filter, sort, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing reviews for productID %s: %v\n", product.ProductID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan ulasan",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    reviews,
+		"summary": fiber.Map{
+			"rating_average": product.RatingAverage,
+			"rating_count":   product.RatingCount,
+		},
+		"pagination": page,
+	})
+}
+
+// CreateReview menyimpan ulasan dari pembeli terverifikasi. Body bisa berupa JSON atau
+// multipart form dengan field rating, comment, dan hingga tiga file "photos".
+func CreateReview(c *fiber.Ctx) error {
+	req, ok := parseReviewRequest(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+
+	product, err := repository.FindProductByProductID(ctx, c.Params("product_id"))
+	if err != nil {
+		return reviewProductError(c, err)
+	}
+
+	verified, err := repository.HasCompletedPurchase(ctx, userID, product.ProductID)
+	if err != nil {
+		log.Printf("Error verifying purchase for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memeriksa riwayat pembelian",
+		})
+	}
+	if !verified {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "Hanya pembeli yang sudah menyelesaikan pesanan yang dapat memberi ulasan",
+		})
+	}
+
+	if _, err := repository.FindReviewByUser(ctx, product.ProductID, userID); err == nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Anda sudah memberi ulasan untuk produk ini",
+		})
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return currentUserError(c, err)
+	}
+
+	photos, errMessage := saveReviewPhotos(c)
+	if errMessage != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   errMessage,
+		})
+	}
+
+	// Ulasan baru menunggu persetujuan admin sebelum tampil dan dihitung dalam rating
+	now := time.Now()
+	review := models.Review{
+		ReviewID:   uuid.New().String(),
+		ProductID:  product.ProductID,
+		UserID:     userID,
+		UserName:   user.Name,
+		Rating:     req.Rating,
+		Comment:    strings.TrimSpace(req.Comment),
+		PhotoURLs:  photos,
+		Status:     models.ReviewPending,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+
+	err = repository.InsertReview(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		removeReviewPhotos(photos)
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Anda sudah memberi ulasan untuk produk ini",
+		})
+	}
+	if err != nil {
+		removeReviewPhotos(photos)
+		log.Printf("Error creating review for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyimpan ulasan",
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Ulasan berhasil dikirim dan menunggu moderasi",
+		"data":    review,
+	})
+}
+
+// UpdateMyReview mengubah ulasan milik user; ulasan yang diubah kembali menunggu moderasi.
+// Foto lama hanya diganti jika file "photos" baru dikirim.
+func UpdateMyReview(c *fiber.Ctx) error {
+	req, ok := parseReviewRequest(c)
+	if !ok {
+		return nil
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	productID := c.Params("product_id")
+
+	review, err := repository.FindReviewByUser(ctx, productID, userID)
+	if err != nil {
+		return reviewNotFoundError(c, err)
+	}
+
+	photos, errMessage := saveReviewPhotos(c)
+	if errMessage != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   errMessage,
+		})
+	}
+	fields := bson.M{
+		"rating":      req.Rating,
+		"comment":     strings.TrimSpace(req.Comment),
+		"status":      models.ReviewPending,
+		"modified_at": time.Now(),
+	}
+	if len(photos) > 0 {
+		fields["photo_urls"] = photos
+	}
+
+	if err := repository.UpdateReview(ctx, review.ReviewID, fields); err != nil {
+		removeReviewPhotos(photos)
+		log.Printf("Error updating review %s: %v\n", review.ReviewID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui ulasan",
+		})
+	}
+	if len(photos) > 0 {
+		removeReviewPhotos(review.PhotoURLs)
+	}
+	refreshProductRating(c, productID, review.Status)
+
+	review, _ = repository.FindReviewByID(ctx, review.ReviewID)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Ulasan berhasil diperbarui dan menunggu moderasi",
+		"data":    review,
+	})
+}
+
+// DeleteMyReview menghapus ulasan milik user untuk sebuah produk
+func DeleteMyReview(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	productID := c.Params("product_id")
+
+	review, err := repository.FindReviewByUser(ctx, productID, userID)
+	if err != nil {
+		return reviewNotFoundError(c, err)
+	}
+
+	if err := repository.DeleteReview(ctx, review.ReviewID); err != nil {
+		log.Printf("Error deleting review %s: %v\n", review.ReviewID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus ulasan",
+		})
+	}
+	removeReviewPhotos(review.PhotoURLs)
+	refreshProductRating(c, productID, review.Status)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Ulasan berhasil dihapus",
+	})
+}
+
+// AdminListReviews mengembalikan ulasan untuk moderasi, bisa difilter dengan ?status= dan ?product_id=
+func AdminListReviews(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	sort, ok := reviewSorts[c.Query("sort", "newest")]
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parameter sort tidak valid",
+		})
+	}
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		filter["product_id"] = productID
+	}
+
+	reviews, total, err := repository.ListReviews(c.UserContext(), filter, sort, page.Page, page.Limit)
+	if err != nil {
+		log.Printf("Error listing reviews: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan ulasan",
+		})
+	}
+	page.Total = total
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       reviews,
+		"pagination": page,
+	})
+}
+
+// AdminModerateReview menyetujui atau menyembunyikan ulasan lalu memperbarui rating produk
+func AdminModerateReview(c *fiber.Ctx) error {
+	var req struct {
+		Status string `json:"status" validate:"required,oneof=approved hidden"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	ctx := c.UserContext()
+	reviewID := c.Params("review_id")
+
+	review, err := repository.FindReviewByID(ctx, reviewID)
+	if err != nil {
+		return reviewNotFoundError(c, err)
+	}
+
+	err = repository.UpdateReview(ctx, reviewID, bson.M{"status": req.Status, "modified_at": time.Now()})
+	if err != nil {
+		log.Printf("Error moderating review %s: %v\n", reviewID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memperbarui status ulasan",
+		})
+	}
+	refreshProductRating(c, review.ProductID, review.Status, req.Status)
+
+	review.Status = req.Status
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Status ulasan berhasil diperbarui",
+		"data":    review,
+	})
+}
+
+// parseReviewRequest membaca dan memvalidasi rating dan komentar; response error sudah dikirim jika ok false
+func parseReviewRequest(c *fiber.Ctx) (models.ReviewRequest, bool) {
+	var req models.ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+		return req, false
+	}
+	if err := validate.Struct(req); err != nil {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+		return req, false
+	}
+	return req, true
+}
+
+// saveReviewPhotos menyimpan file "photos" dari multipart form dan mengembalikan URL-nya.
+// Pesan error dikembalikan jika file tidak valid; request tanpa foto menghasilkan nil.
+func saveReviewPhotos(c *fiber.Ctx) ([]string, string) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, ""
+	}
+	files := form.File["photos"]
+	if len(files) == 0 {
+		return nil, ""
+	}
+	if len(files) > maxReviewPhotos {
+		return nil, fmt.Sprintf("Maksimal %d foto per ulasan", maxReviewPhotos)
+	}
+
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		if ext != ".png" && ext != ".jpeg" && ext != ".jpg" {
+			return nil, "Tipe file tidak valid. Hanya file PNG, JPEG, atau JPG yang diperbolehkan"
+		}
+		if file.Size > maxReviewPhotoSize {
+			return nil, "Ukuran foto ulasan maksimal 2 MB"
+		}
+	}
+
+	if err := os.MkdirAll(reviewPhotoDir, os.ModePerm); err != nil {
+		return nil, "Gagal membuat direktori review-photos"
+	}
+
+	var urls []string
+	for _, file := range files {
+		fileName := uuid.New().String() + strings.ToLower(filepath.Ext(file.Filename))
+		if err := c.SaveFile(file, filepath.Join(reviewPhotoDir, fileName)); err != nil {
+			log.Println("Error saving review photo:", err)
+			removeReviewPhotos(urls)
+			return nil, "Gagal menyimpan foto ulasan"
+		}
+		urls = append(urls, fmt.Sprintf("http://localhost:3000/%s", path.Join("review-photos", fileName)))
+	}
+	return urls, ""
+}
+
+// removeReviewPhotos menghapus file foto ulasan; kegagalan hanya dicatat
+func removeReviewPhotos(urls []string) {
+	for _, url := range urls {
+		name := path.Base(url)
+		if err := os.Remove(filepath.Join(reviewPhotoDir, name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing review photo %s: %v\n", name, err)
+		}
+	}
+}
+
+// refreshProductRating menghitung ulang rating produk jika status ulasan sebelum atau
+// sesudah perubahan adalah approved, karena hanya ulasan approved yang dihitung
+func refreshProductRating(c *fiber.Ctx, productID string, statuses ...string) {
+	affected := false
+	for _, status := range statuses {
+		affected = affected || status == models.ReviewApproved
+	}
+	if !affected {
+		return
+	}
+	if err := repository.RefreshProductRating(c.UserContext(), productID); err != nil {
+		log.Printf("Error refreshing rating for productID %s: %v\n", productID, err)
+	}
+}
+
+func reviewProductError(c *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk tidak ditemukan",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Gagal mendapatkan produk",
+	})
+}
+
+func reviewNotFoundError(c *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Ulasan tidak ditemukan",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Gagal mendapatkan ulasan",
+	})
+}
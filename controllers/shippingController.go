@@ -1 +1,101 @@
 package controllers
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"be-stepup/shipping"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Penyedia ongkos kirim dan alamat gudang asal, dipilih dari konfigurasi saat startup
+var (
+	shippingProvider = shipping.NewProviderFromConfig()
+	shippingOrigin   = shipping.OriginFromConfig()
+)
+
+// cartWeight menghitung total berat kirim item keranjang dari data produk
+func cartWeight(ctx context.Context, items []models.CartItem) (int, error) {
+	productCollection := config.GetCollection("products")
+	weight := 0
+	for _, item := range items {
+		var product models.Product
+		err := productCollection.FindOne(ctx, bson.M{"product_id": item.ProductID}).Decode(&product)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !product.Available(time.Now()) {
+			continue
+		}
+		weight += shipping.ItemWeight(product) * item.Quantity
+	}
+	return weight, nil
+}
+
+// GetShippingRates mengembalikan pilihan kurir dan ongkos kirim untuk isi keranjang user
+func GetShippingRates(c *fiber.Ctx) error {
+	var request struct {
+		ShippingAddress models.Address `json:"shipping_address" validate:"required"`
+		Courier         string         `json:"courier"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(string)
+	ctx := c.UserContext()
+
+	var cart models.Cart
+	err := config.GetCollection("cart").FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Keranjang tidak ditemukan",
+		})
+	}
+
+	weight, err := cartWeight(ctx, cart.Items)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung berat keranjang",
+		})
+	}
+
+	rates, err := shippingProvider.Rates(ctx, shipping.RateRequest{
+		Origin:      shippingOrigin,
+		Destination: request.ShippingAddress,
+		WeightGrams: weight,
+		Courier:     request.Courier,
+	})
+	if err != nil {
+		log.Printf("Error fetching shipping rates for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan ongkos kirim",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"weight_grams": weight,
+		"data":         rates,
+	})
+}
@@ -1,9 +1,7 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
-	"time"
 
 	"be-stepup/config"
 	"be-stepup/models"
@@ -14,8 +12,7 @@ import (
 // GetAllUsers retrieves all users from the database
 func GetAllUsers(c *fiber.Ctx) error {
 	// Membuat konteks dengan timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	// Mengambil koleksi user dari database
 	userCollection := config.GetCollection("users")
@@ -46,8 +43,7 @@ func GetUserByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	// Membuat konteks dengan timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.UserContext()
 
 	// Mengambil koleksi user dari database
 	userCollection := config.GetCollection("users")
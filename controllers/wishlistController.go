@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"be-stepup/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"time"
+)
+
+// wishlistEntry adalah item wishlist beserta data produk terbaru
+type wishlistEntry struct {
+	ProductID   string      `json:"product_id"`
+	ProductCode string      `json:"product_code"`
+	ProductName string      `json:"product_name"`
+	Price       money.Money `json:"price"`
+	ImageURL    string      `json:"image_url"`
+	InStock     bool        `json:"in_stock"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// GetMyWishlist mengembalikan wishlist user yang sedang login beserta harga dan stok terbaru
+func GetMyWishlist(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+
+	items, err := repository.ListWishlistItems(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing wishlist for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan wishlist",
+		})
+	}
+
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := repository.FindProductsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+	}
+
+	// Produk yang sudah dihapus atau tidak tayang tidak ditampilkan
+	products = availableProducts(products, time.Now())
+	entries := make([]wishlistEntry, 0, len(items))
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			continue
+		}
+		entries = append(entries, wishlistEntry{
+			ProductID:   product.ProductID,
+			ProductCode: product.Code,
+			ProductName: product.Name,
+			Price:       product.Price,
+			ImageURL:    product.ImageURL,
+			InStock:     product.Stock > 0,
+			CreatedAt:   item.CreatedAt,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// AddToWishlist menyimpan produk ke wishlist user yang sedang login
+func AddToWishlist(c *fiber.Ctx) error {
+	var request struct {
+		ProductID string `json:"product_id" validate:"required"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+
+	product, err := repository.FindProductByProductID(ctx, request.ProductID)
+	if err == nil && !product.Available(time.Now()) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Produk tidak ditemukan",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan produk",
+		})
+	}
+
+	created, err := repository.AddWishlistItem(ctx, userID, request.ProductID)
+	if err != nil {
+		log.Printf("Error adding wishlist item for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyimpan wishlist",
+		})
+	}
+
+	status, message := http.StatusCreated, "Produk berhasil disimpan ke wishlist"
+	if !created {
+		status, message = http.StatusOK, "Produk sudah ada di wishlist"
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
+
+// RemoveFromWishlist menghapus produk dari wishlist user yang sedang login
+func RemoveFromWishlist(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	err := repository.RemoveWishlistItem(c.UserContext(), userID, c.Params("product_id"))
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Produk tidak ada di wishlist",
+		})
+	}
+	if err != nil {
+		log.Printf("Error removing wishlist item for userID %s: %v\n", userID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghapus produk dari wishlist",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Produk berhasil dihapus dari wishlist",
+	})
+}
+
+// MoveWishlistToCart memindahkan produk dari wishlist ke keranjang dengan pemeriksaan stok
+// yang sama seperti AddToCart. Produk baru dihapus dari wishlist setelah masuk keranjang.
+func MoveWishlistToCart(c *fiber.Ctx) error {
+	var request struct {
+		Quantity int `json:"quantity" validate:"omitempty,min=1"`
+	}
+	if err := c.BodyParser(&request); err != nil && len(c.Body()) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body request tidak valid",
+		})
+	}
+	if err := validate.Struct(request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Data tidak valid: " + err.Error(),
+		})
+	}
+	if request.Quantity == 0 {
+		request.Quantity = 1
+	}
+
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(string)
+	productID := c.Params("product_id")
+
+	if _, err := repository.FindWishlistItem(ctx, userID, productID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Produk tidak ada di wishlist",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mendapatkan wishlist",
+		})
+	}
+
+	cartItems, err := addToCart(ctx, userID, productID, request.Quantity)
+	if err != nil {
+		return addToCartError(c, err)
+	}
+
+	if err := repository.RemoveWishlistItem(ctx, userID, productID); err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error removing moved wishlist item for userID %s: %v\n", userID, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Produk berhasil dipindahkan ke keranjang",
+		"cart":    cartItems,
+	})
+}
+
+// GetWishlistCounts mengembalikan produk yang paling banyak disimpan di wishlist (khusus admin)
+func GetWishlistCounts(c *fiber.Ctx) error {
+	page := parsePagination(c)
+
+	counts, err := repository.WishlistCounts(c.UserContext(), page.Limit)
+	if err != nil {
+		log.Printf("Error counting wishlists: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung wishlist",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    counts,
+	})
+}
+
+// GetProductWishlistCount mengembalikan jumlah user yang menyimpan satu produk di wishlist (khusus admin)
+func GetProductWishlistCount(c *fiber.Ctx) error {
+	productID := c.Params("product_id")
+
+	count, err := repository.CountWishlistByProduct(c.UserContext(), productID)
+	if err != nil {
+		log.Printf("Error counting wishlist for productID %s: %v\n", productID, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menghitung wishlist",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    models.WishlistCount{ProductID: productID, Users: count},
+	})
+}
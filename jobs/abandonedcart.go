@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// AbandonedCartName adalah nama job pengingat dan pembersihan keranjang terbengkalai
+const AbandonedCartName = "abandoned-carts"
+
+// abandonedCartBatch membatasi jumlah pengingat yang dikirim dalam satu run
+const abandonedCartBatch = 500
+
+// AbandonedCartConfig mengatur kapan keranjang dianggap terbengkalai dan kapan dihapus
+type AbandonedCartConfig struct {
+	RemindAfter time.Duration // Keranjang yang tidak diubah selama ini mendapat pengingat
+	ExpireAfter time.Duration // Keranjang yang tidak diubah selama ini dihapus
+}
+
+// AbandonedCarts membuat job yang menghapus keranjang kedaluwarsa lalu mengantrekan
+// pengingat untuk keranjang user yang terbengkalai
+func AbandonedCarts(interval time.Duration, cfg AbandonedCartConfig) Job {
+	return Job{
+		Name:     AbandonedCartName,
+		Interval: interval,
+		Run: func(ctx context.Context) (Result, error) {
+			return runAbandonedCarts(ctx, cfg)
+		},
+	}
+}
+
+func runAbandonedCarts(ctx context.Context, cfg AbandonedCartConfig) (Result, error) {
+	now := time.Now()
+	result := Result{}
+
+	// Keranjang kedaluwarsa dihapus lebih dulu agar tidak ikut diingatkan
+	expired, err := repository.DeleteCartsModifiedBefore(ctx, now.Add(-cfg.ExpireAfter))
+	if err != nil {
+		return result, fmt.Errorf("expire carts: %w", err)
+	}
+	result["expired"] = expired
+
+	carts, err := repository.FindAbandonedCarts(ctx, now.Add(-cfg.RemindAfter), abandonedCartBatch)
+	if err != nil {
+		return result, fmt.Errorf("find abandoned carts: %w", err)
+	}
+
+	for _, cart := range carts {
+		// Penandaan bersifat atomik sehingga keranjang yang sama tidak diingatkan dua kali
+		claimed, err := repository.MarkCartReminded(ctx, cart.CartID, cart.ModifiedAt, now)
+		if err != nil {
+			return result, fmt.Errorf("mark cart %s reminded: %w", cart.CartID, err)
+		}
+		if !claimed {
+			result["skipped"]++
+			continue
+		}
+
+		if _, err := repository.EnqueueNotification(ctx, cartReminder(cart)); err != nil {
+			log.Printf("Error enqueueing cart reminder for userID %s: %v\n", cart.UserID, err)
+			result["failed"]++
+			continue
+		}
+		result["reminded"]++
+	}
+	return result, nil
+}
+
+func cartReminder(cart models.Cart) models.Notification {
+	quantity := 0
+	for _, item := range cart.Items {
+		quantity += item.Quantity
+	}
+	return models.Notification{
+		UserID:  cart.UserID,
+		Type:    models.NotificationCartReminder,
+		Title:   "Keranjang belanja Anda menunggu",
+		Message: fmt.Sprintf("Masih ada %d produk di keranjang Anda. Selesaikan pesanan sebelum stoknya habis.", quantity),
+		Data: map[string]interface{}{
+			"cart_id":     cart.CartID,
+			"items":       len(cart.Items),
+			"modified_at": cart.ModifiedAt,
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"be-stepup/audit"
+	"be-stepup/models"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// CheckoutExpiryName adalah nama job pembatalan checkout yang tidak dibayar
+const CheckoutExpiryName = "checkout-expiry"
+
+// checkoutExpiryBatch membatasi jumlah checkout yang dibatalkan dalam satu run
+const checkoutExpiryBatch = 200
+
+// checkoutReleaseRetryDelay adalah jeda sebelum pengembalian stok checkout Cancelled yang belum
+// tercatat diulang, agar tidak berebut dengan pembatalan oleh admin yang masih berjalan
+const checkoutReleaseRetryDelay = 5 * time.Minute
+
+// CheckoutExpiry membuat job yang membatalkan checkout Pending yang melewati batas waktu
+// pembayaran tanpa bukti pembayaran, lalu mengembalikan stok dan kuota promosinya. Checkout
+// Cancelled yang pengembaliannya gagal sebelumnya (stock_released_at kosong) dicoba lagi.
+func CheckoutExpiry(interval time.Duration) Job {
+	return Job{
+		Name:     CheckoutExpiryName,
+		Interval: interval,
+		Run:      runCheckoutExpiry,
+	}
+}
+
+func runCheckoutExpiry(ctx context.Context) (Result, error) {
+	now := time.Now()
+	result := Result{}
+
+	checkouts, err := repository.FindExpiredCheckouts(ctx, now, checkoutExpiryBatch)
+	if err != nil {
+		return result, fmt.Errorf("find expired checkouts: %w", err)
+	}
+
+	for _, checkout := range checkouts {
+		// Bukti pembayaran yang menunggu verifikasi atau sudah diverifikasi menahan pembatalan;
+		// bukti yang ditolak tidak, agar stoknya tidak tertahan selamanya
+		paid, err := repository.HasActivePaymentForCheckout(ctx, checkout.CheckoutID)
+		if err != nil {
+			return result, fmt.Errorf("check payment for checkout %s: %w", checkout.CheckoutID, err)
+		}
+		if paid {
+			result["awaiting_verification"]++
+			continue
+		}
+
+		cancelled, err := repository.CancelExpiredCheckout(ctx, checkout.CheckoutID, now)
+		if err != nil {
+			return result, fmt.Errorf("cancel checkout %s: %w", checkout.CheckoutID, err)
+		}
+		if !cancelled {
+			result["skipped"]++
+			continue
+		}
+		result["cancelled"]++
+		audit.Log(ctx, models.AuditEvent{
+			Actor:      systemActor(CheckoutExpiryName),
+			Action:     models.AuditCheckoutStatus,
+			TargetType: "checkout",
+			TargetID:   checkout.CheckoutID,
+			Details: map[string]interface{}{
+				"previous_status": checkout.Status,
+				"status":          models.CheckoutStatusCancelled,
+				"reason":          models.CheckoutCancelPaymentExpired,
+			},
+		})
+
+		if err := productservice.ReleaseCheckout(ctx, checkout, systemActor(CheckoutExpiryName), "payment deadline passed"); err != nil {
+			log.Printf("Error releasing reservations of cancelled checkout %s: %v\n", checkout.CheckoutID, err)
+			result["restore_failed"]++
+		}
+	}
+
+	unreleased, err := repository.FindUnreleasedCheckouts(ctx, now.Add(-checkoutReleaseRetryDelay), checkoutExpiryBatch)
+	if err != nil {
+		return result, fmt.Errorf("find unreleased checkouts: %w", err)
+	}
+	for _, checkout := range unreleased {
+		if err := productservice.ReleaseCheckout(ctx, checkout, systemActor(CheckoutExpiryName), "retry release of cancelled checkout"); err != nil {
+			log.Printf("Error retrying release of cancelled checkout %s: %v\n", checkout.CheckoutID, err)
+			result["restore_failed"]++
+			continue
+		}
+		result["restore_retried"]++
+	}
+	return result, nil
+}
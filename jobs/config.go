@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"be-stepup/config"
+	"time"
+)
+
+// NewFromConfig membuat Scheduler dengan job bawaan. JOBS_ENABLED=false mematikan semua job
+// pada replika ini, misalnya untuk replika yang hanya melayani HTTP.
+func NewFromConfig() *Scheduler {
+	scheduler := NewScheduler()
+	if config.Config("JOBS_ENABLED") == "false" {
+		return scheduler
+	}
+
+	scheduler.Register(AbandonedCarts(
+		config.ConfigDuration("ABANDONED_CART_INTERVAL", 15*time.Minute),
+		AbandonedCartConfig{
+			RemindAfter: config.ConfigDuration("ABANDONED_CART_REMIND_AFTER", 24*time.Hour),
+			ExpireAfter: config.ConfigDuration("ABANDONED_CART_EXPIRE_AFTER", 30*24*time.Hour),
+		},
+	))
+	scheduler.Register(CheckoutExpiry(config.ConfigDuration("CHECKOUT_EXPIRY_INTERVAL", 5*time.Minute)))
+	scheduler.Register(ProductSchedule(config.ConfigDuration("PRODUCT_SCHEDULE_INTERVAL", time.Minute)))
+	return scheduler
+}
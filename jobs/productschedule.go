@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"be-stepup/models"
+	"be-stepup/productservice"
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ProductScheduleName adalah nama job penayangan produk terjadwal
+const ProductScheduleName = "product-schedule"
+
+// ProductSchedule membuat job yang mengaktifkan draft saat publish_at tiba dan mengarsipkan
+// produk aktif saat unpublish_at lewat. Katalog publik sudah memperhitungkan jadwal secara
+// langsung, job ini menyimpan status akhirnya agar admin melihat status yang sebenarnya.
+// Setiap produk diubah satu per satu lewat productservice agar tercatat di riwayat produk.
+func ProductSchedule(interval time.Duration) Job {
+	return Job{
+		Name:     ProductScheduleName,
+		Interval: interval,
+		Run:      runProductSchedule,
+	}
+}
+
+func runProductSchedule(ctx context.Context) (Result, error) {
+	now := time.Now()
+	result := Result{}
+
+	products, err := repository.FindScheduledProducts(ctx, now)
+	if err != nil {
+		return result, fmt.Errorf("find scheduled products: %w", err)
+	}
+
+	for _, product := range products {
+		after, changed, err := productservice.ApplySchedule(ctx, product, now, systemActor(ProductScheduleName))
+		if err != nil {
+			return result, fmt.Errorf("apply schedule of product %s: %w", product.ProductID, err)
+		}
+		switch {
+		case !changed:
+			result["skipped"]++
+		case after.Status == models.ProductActive:
+			result["published"]++
+		default:
+			result["unpublished"]++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+// Package jobs menjalankan tugas latar belakang berkala di dalam proses server.
+//
+// Setiap replika menjalankan Scheduler yang sama, tetapi sebuah job hanya
+// dijalankan oleh replika yang memegang lease-nya di koleksi job_locks.
+// Pemegang lease memperpanjang lease setiap interval; replika lain baru
+// mengambil alih setelah lease habis, misalnya ketika pemegangnya mati.
+package jobs
+
+import (
+	"be-stepup/repository"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Result berisi penghitung hasil satu kali run, misalnya jumlah keranjang yang diingatkan
+type Result map[string]int64
+
+// Job adalah tugas yang dijalankan setiap Interval. Run dibatalkan jika melebihi Interval
+// agar tidak tumpang tindih dengan lease berikutnya.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (Result, error)
+}
+
+// Scheduler menjalankan job yang terdaftar sampai context dibatalkan
+type Scheduler struct {
+	instanceID string
+	jobs       []Job
+	wg         sync.WaitGroup
+}
+
+// NewScheduler membuat Scheduler dengan identitas replika yang unik
+func NewScheduler() *Scheduler {
+	return &Scheduler{instanceID: InstanceID()}
+}
+
+var instanceID = newInstanceID()
+
+// InstanceID mengembalikan identitas replika ini sebagai pemegang lease
+func InstanceID() string {
+	return instanceID
+}
+
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "stepup"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+}
+
+// systemActor adalah actor yang dicatat untuk perubahan data oleh job
+func systemActor(jobName string) string {
+	return repository.SystemActor + ":" + jobName
+}
+
+// Register menambahkan job; harus dipanggil sebelum Start
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start menjalankan setiap job di goroutine sendiri, langsung sekali lalu setiap interval
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			log.Printf("Job %s scheduled every %s on %s", job.Name, job.Interval, s.instanceID)
+
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				s.runOnce(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait menunggu semua job yang sedang berjalan selesai setelah context dibatalkan
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	startedAt := time.Now()
+	acquired, err := repository.AcquireJobLease(ctx, job.Name, s.instanceID, job.Interval, startedAt, startedAt.Add(job.Interval))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error acquiring lease for job %s: %v\n", job.Name, err)
+		}
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Interval)
+	result, runErr := job.Run(runCtx)
+	cancel()
+
+	finishedAt := time.Now()
+	if runErr != nil {
+		log.Printf("Job %s failed: %v\n", job.Name, runErr)
+	}
+	if err := repository.FinishJobRun(ctx, job.Name, s.instanceID, finishedAt, finishedAt.Sub(startedAt), result, runErr); err != nil && ctx.Err() == nil {
+		log.Printf("Error recording run of job %s: %v\n", job.Name, err)
+	}
+}
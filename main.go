@@ -3,8 +3,11 @@ package main
 import (
 	"be-stepup/config"
 	"be-stepup/controllers"
+	"be-stepup/jobs"
 	"be-stepup/middleware"
+	"be-stepup/migrations"
 	"be-stepup/routes"
+	"context"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -12,20 +15,39 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
+	// Subcommand `migrate` menjalankan migrasi database tanpa menyalakan server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(os.Args[2:])
+		return
+	}
+
 	// Inisialisasi aplikasi Fiber
 	app := fiber.New()
 
+	// Context dasar untuk semua request, dibatalkan ketika masa tenggang shutdown habis
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	// Middleware untuk request ID dan deadline per request
+	app.Use(middleware.RequestContext(baseCtx, config.ConfigDuration("REQUEST_TIMEOUT", 10*time.Second)))
+
 	// Middleware untuk logging
-	app.Use(logger.New())
+	app.Use(logger.New(logger.Config{
+		Format: "[${time}] ${locals:requestID} ${status} - ${latency} ${method} ${path}\n",
+	}))
 
 	// Middleware untuk mengatasi CORS (Didefinisikan sebelum rute)
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "http://127.0.0.1:5500, https://narasaon.me",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  "http://127.0.0.1:5500, https://narasaon.me",
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-Request-ID, X-Cart-Token, If-Match",
+		ExposeHeaders: "X-Request-ID, X-Cart-Token, ETag",
+		// Cookie token keranjang tamu ikut dikirim dari frontend
+		AllowCredentials: true,
 	}))
 
 	// Menghubungkan ke database MongoDB
@@ -35,6 +57,20 @@ func main() {
 	}
 	defer config.DisconnectDB() // Menutup koneksi ke MongoDB ketika server dimatikan
 
+	// Menjalankan migrasi saat startup jika RUN_MIGRATIONS=true
+	if config.Config("RUN_MIGRATIONS") == "true" {
+		migrateCtx, cancelMigrate := context.WithTimeout(baseCtx, 5*time.Minute)
+		err := migrations.Run(migrateCtx, config.GetDatabase())
+		cancelMigrate()
+		if err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
+
+	// Menjalankan job latar belakang; hanya satu replika yang memegang lease setiap job
+	scheduler := jobs.NewFromConfig()
+	scheduler.Start(baseCtx)
+
 	// Atur semua rute
 	routes.SetupRoutes(app)
 
@@ -44,8 +80,8 @@ func main() {
 	// Route untuk login
 	app.Post("/api/login", controllers.Login)
 
-	// Proteksi endpoint AddToCart dengan middleware JWT
-	app.Post("/cart/add", middleware.JWTAuthMiddleware, controllers.AddToCart)
+	// Endpoint AddToCart lama, kini juga bisa dipakai tamu
+	app.Post("/cart/add", middleware.CartIdentity, controllers.AddToCart)
 
 	// Melayani file statis dari folder `payment`
 	app.Static("/payment", "./payment")
@@ -53,6 +89,12 @@ func main() {
 	// Middleware untuk melayani file statis dari folder uploads
 	app.Static("/uploads", "./uploads")
 
+	// Melayani foto profil user dari folder avatars
+	app.Static("/avatars", "./avatars")
+
+	// Melayani foto ulasan produk
+	app.Static("/review-photos", "./review-photos")
+
 	// Menyiapkan channel untuk menangkap sinyal shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -68,10 +110,15 @@ func main() {
 
 	// Menunggu sinyal shutdown
 	<-c
-	log.Println("Gracefully shutting down...")
-	if err := app.Shutdown(); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	gracePeriod := config.ConfigDuration("SHUTDOWN_GRACE_PERIOD", 15*time.Second)
+	log.Printf("Gracefully shutting down, waiting up to %s for in-flight requests...", gracePeriod)
+	if err := app.ShutdownWithTimeout(gracePeriod); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Batalkan operasi database yang masih berjalan setelah masa tenggang habis
+	cancelBase()
+	scheduler.Wait()
+
 	log.Println("Server stopped")
 }
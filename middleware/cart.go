@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"be-stepup/package/token"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// Token keranjang tamu dikirim lewat cookie atau header; header dipakai klien yang tidak menyimpan cookie
+const (
+	CartTokenCookie = "cart_token"
+	CartTokenHeader = "X-Cart-Token"
+)
+
+// cartTokenMaxAge mengikuti umur keranjang sebelum dibersihkan oleh purge-carts
+const cartTokenMaxAge = 30 * 24 * time.Hour
+
+// CartIdentity mengizinkan rute keranjang dipakai oleh user yang login maupun tamu.
+// Jika header Authorization ada, request diteruskan ke JWTAuthMiddleware. Jika tidak,
+// token keranjang tamu diverifikasi atau dibuat baru, lalu ID tamu disimpan di Locals("guestID").
+func CartIdentity(c *fiber.Ctx) error {
+	if c.Get("Authorization") != "" {
+		return JWTAuthMiddleware(c)
+	}
+
+	guestID, err := token.ParseCartToken(GuestCartToken(c))
+	if err != nil {
+		var cartToken string
+		guestID, cartToken = token.GenerateCartToken()
+		c.Cookie(&fiber.Cookie{
+			Name:     CartTokenCookie,
+			Value:    cartToken,
+			Path:     "/",
+			MaxAge:   int(cartTokenMaxAge.Seconds()),
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+		c.Set(CartTokenHeader, cartToken)
+	}
+
+	c.Locals("guestID", guestID)
+	return c.Next()
+}
+
+// GuestCartToken mengambil token keranjang tamu dari header atau cookie
+func GuestCartToken(c *fiber.Ctx) string {
+	if cartToken := c.Get(CartTokenHeader); cartToken != "" {
+		return cartToken
+	}
+	return c.Cookies(CartTokenCookie)
+}
+
+// ClearGuestCartToken menghapus cookie keranjang tamu setelah keranjang digabung ke akun user
+func ClearGuestCartToken(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     CartTokenCookie,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"be-stepup/package/reqctx"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+// RequestIDHeader adalah header yang membawa request ID dari dan ke klien
+const RequestIDHeader = "X-Request-ID"
+
+const baseContextKey = "baseContext"
+
+// RequestContext membuat context per request yang membawa request ID dan deadline.
+// Context diturunkan dari base sehingga pembatalan base (saat shutdown) ikut
+// membatalkan semua operasi database yang sedang berjalan.
+func RequestContext(base context.Context, timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Set(RequestIDHeader, requestID)
+		c.Locals("requestID", requestID)
+		c.Locals(baseContextKey, base)
+
+		ctx, cancel := context.WithTimeout(reqctx.WithRequestID(base, requestID), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// Timeout mengganti deadline bawaan untuk satu rute, misalnya upload file yang butuh waktu lebih lama.
+// Nilai pada context tetap dipertahankan dan pembatalan saat shutdown tetap berlaku.
+func Timeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.UserContext()), timeout)
+		defer cancel()
+
+		if base, ok := c.Locals(baseContextKey).(context.Context); ok {
+			stop := context.AfterFunc(base, cancel)
+			defer stop()
+		}
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// Detach membuat context untuk pekerjaan yang berlanjut setelah response dikirim, misalnya
+// job impor. Nilai request tetap dibawa dan deadline request tidak berlaku, tetapi context
+// tetap dibatalkan saat server shutdown.
+func Detach(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.UserContext()))
+	if base, ok := c.Locals(baseContextKey).(context.Context); ok {
+		stop := context.AfterFunc(base, cancel)
+		return ctx, func() {
+			stop()
+			cancel()
+		}
+	}
+	return ctx, cancel
+}
@@ -2,10 +2,13 @@ package middleware
 
 import (
 	"be-stepup/config"
+	"be-stepup/package/reqctx"
 	"be-stepup/package/token"
+	"be-stepup/repository"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/mongo"
 	"strings"
 )
 
@@ -44,8 +47,28 @@ func JWTAuthMiddleware(c *fiber.Ctx) error {
 	}
 
 	if tkn.Valid {
+		// Role dan status akun dibaca dari database agar perubahan oleh admin langsung berlaku
+		user, err := repository.FindUserByID(c.UserContext(), claims.UserID)
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to load user",
+			})
+		}
+		if user.Disabled {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Account is disabled",
+			})
+		}
+
 		c.Locals("userID", claims.UserID)
+		c.Locals("role", user.Role)
 		c.Locals("claims", claims)
+		c.SetUserContext(reqctx.WithUserID(c.UserContext(), claims.UserID))
 		return c.Next()
 	}
 
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"be-stepup/config"
+	"be-stepup/migrations"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runMigrateCommand menangani subcommand `migrate` dan `migrate status`
+func runMigrateCommand(args []string) {
+	if err := config.ConnectDB(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer config.DisconnectDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if len(args) > 0 && args[0] == "status" {
+		statuses, err := migrations.List(ctx, config.GetDatabase())
+		if err != nil {
+			log.Fatalf("Failed to list migrations: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-50s  %s\n", s.Version, s.Description, state)
+		}
+		return
+	}
+
+	if err := migrations.Run(ctx, config.GetDatabase()); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	log.Println("Migrations are up to date")
+}
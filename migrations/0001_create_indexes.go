@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     1,
+		Description: "create indexes for lookup fields",
+		Up:          createIndexes,
+	})
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	unique := func(field string) mongo.IndexModel {
+		return mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}
+	}
+	plain := func(field string) mongo.IndexModel {
+		return mongo.IndexModel{Keys: bson.D{{Key: field, Value: 1}}}
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		"products": {unique("product_id"), unique("code")},
+		"users":    {unique("email"), unique("userid")},
+		"cart":     {unique("user_id")},
+		"checkout": {unique("checkout_id"), plain("user_id")},
+		"payment":  {unique("payment_id"), plain("checkout_id")},
+	}
+
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     2,
+		Description: "backfill missing user roles and product stock",
+		Up:          backfillDefaults,
+	})
+}
+
+func backfillDefaults(ctx context.Context, db *mongo.Database) error {
+	// User lama yang mendaftar tanpa role diperlakukan sebagai user biasa
+	_, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"$or": bson.A{bson.M{"role": bson.M{"$exists": false}}, bson.M{"role": ""}}},
+		bson.M{"$set": bson.M{"role": "user"}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("products").UpdateMany(ctx,
+		bson.M{"stock": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"stock": 0}},
+	)
+	return err
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     3,
+		Description: "add JSON schema validators for users and products",
+		Up:          addSchemaValidators,
+	})
+}
+
+func addSchemaValidators(ctx context.Context, db *mongo.Database) error {
+	users := bson.M{
+		"bsonType": "object",
+		"required": bson.A{"userid", "email", "password", "role"},
+		"properties": bson.M{
+			"userid":   bson.M{"bsonType": "string", "minLength": 1},
+			"email":    bson.M{"bsonType": "string", "minLength": 3},
+			"password": bson.M{"bsonType": "string", "minLength": 1},
+			"role":     bson.M{"bsonType": "string"},
+		},
+	}
+	if err := setValidator(ctx, db, "users", users); err != nil {
+		return err
+	}
+
+	products := bson.M{
+		"bsonType": "object",
+		"required": bson.A{"product_id", "code", "name", "price", "stock"},
+		"properties": bson.M{
+			"product_id": bson.M{"bsonType": "string", "minLength": 1},
+			"code":       bson.M{"bsonType": "string", "minLength": 1},
+			"name":       bson.M{"bsonType": "string"},
+			"price":      bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}, "minimum": 0},
+			"stock":      bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+		},
+	}
+	return setValidator(ctx, db, "products", products)
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     4,
+		Description: "convert float prices to money documents in minor units",
+		Up:          convertPricesToMoney,
+	})
+}
+
+// moneyExpr mengubah ekspresi angka rupiah float menjadi {amount: <sen>, currency: "IDR"}
+func moneyExpr(field string) bson.M {
+	return bson.M{
+		"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, 100}}, 0}}},
+		"currency": "IDR",
+	}
+}
+
+// itemsPriceExpr mengonversi price di setiap elemen items yang masih berupa angka
+func itemsPriceExpr() bson.M {
+	return bson.M{"$map": bson.M{
+		"input": "$items",
+		"as":    "item",
+		"in": bson.M{"$mergeObjects": bson.A{"$$item", bson.M{
+			"price": bson.M{"$cond": bson.A{
+				bson.M{"$isNumber": "$$item.price"},
+				moneyExpr("$$item.price"),
+				"$$item.price",
+			}},
+		}}},
+	}}
+}
+
+func convertPricesToMoney(ctx context.Context, db *mongo.Database) error {
+	// Validator produk diperbarui lebih dulu; dokumen lama yang belum valid tetap bisa
+	// diperbarui karena validationLevel "moderate"
+	products := bson.M{
+		"bsonType": "object",
+		"required": bson.A{"product_id", "code", "name", "price", "stock"},
+		"properties": bson.M{
+			"product_id": bson.M{"bsonType": "string", "minLength": 1},
+			"code":       bson.M{"bsonType": "string", "minLength": 1},
+			"name":       bson.M{"bsonType": "string"},
+			"price": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"amount", "currency"},
+				"properties": bson.M{
+					"amount":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+					"currency": bson.M{"bsonType": "string", "minLength": 3, "maxLength": 3},
+				},
+			},
+			"stock": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+		},
+	}
+	if err := setValidator(ctx, db, "products", products); err != nil {
+		return err
+	}
+
+	isNumber := func(field string) bson.M {
+		return bson.M{field: bson.M{"$type": "number"}}
+	}
+
+	_, err := db.Collection("products").UpdateMany(ctx, isNumber("price"),
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"price": moneyExpr("$price")}}}})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"cart", "checkout", "orders"} {
+		_, err := db.Collection(name).UpdateMany(ctx, isNumber("items.price"),
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"items": itemsPriceExpr()}}}})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{"checkout", "orders"} {
+		_, err := db.Collection(name).UpdateMany(ctx, isNumber("total_price"),
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"total_price": moneyExpr("$total_price")}}}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
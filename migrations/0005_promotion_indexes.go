@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     5,
+		Description: "create indexes for promotions and promotion usages",
+		Up:          createPromotionIndexes,
+	})
+}
+
+func createPromotionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("promotions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "promotion_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Hanya voucher yang punya kode; promosi otomatis tidak menyimpan field code
+		{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"code": bson.M{"$exists": true}})},
+		{Keys: bson.D{{Key: "active", Value: 1}, {Key: "starts_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("promotion_usages").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "user_id", Value: 1}},
+	})
+	return err
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     6,
+		Description: "create indexes for wishlists",
+		Up:          createWishlistIndexes,
+	})
+}
+
+func createWishlistIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("wishlists").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// Menghitung jumlah user per produk untuk laporan admin
+		{Keys: bson.D{{Key: "product_id", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     8,
+		Description: "create indexes for abandoned carts and notifications",
+		Up:          createJobIndexes,
+	})
+}
+
+func createJobIndexes(ctx context.Context, db *mongo.Database) error {
+	// Pencarian keranjang terbengkalai dan pembersihan keranjang kedaluwarsa
+	if _, err := db.Collection("cart").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "modified_at", Value: 1}},
+	}); err != nil {
+		return err
+	}
+
+	_, err := db.Collection("notifications").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		// Antrean notifikasi yang belum dikirim
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     9,
+		Description: "create indexes for checkout payment deadlines",
+		Up:          createCheckoutDeadlineIndexes,
+	})
+}
+
+func createCheckoutDeadlineIndexes(ctx context.Context, db *mongo.Database) error {
+	// Pencarian checkout Pending yang sudah melewati batas waktu pembayaran
+	if _, err := db.Collection("checkout").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "payment_deadline", Value: 1}},
+	}); err != nil {
+		return err
+	}
+
+	_, err := db.Collection("promotion_usages").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "checkout_id", Value: 1}},
+	})
+	return err
+}
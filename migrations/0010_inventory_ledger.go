@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func init() {
+	register(Migration{
+		Version:     10,
+		Description: "create inventory ledger indexes and opening balances",
+		Up:          createInventoryLedger,
+	})
+}
+
+func createInventoryLedger(ctx context.Context, db *mongo.Database) error {
+	movements := db.Collection("inventory_movements")
+	_, err := movements.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "movement_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "reference_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Stok produk yang sudah ada dicatat sebagai saldo awal agar jumlah delta sama dengan stok
+	cursor, err := db.Collection("products").Find(ctx, bson.M{"stock": bson.M{"$gt": 0}},
+		options.Find().SetProjection(bson.M{"product_id": 1, "code": 1, "stock": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var products []struct {
+		ProductID string `bson:"product_id"`
+		Code      string `bson:"code"`
+		Stock     int    `bson:"stock"`
+	}
+	if err := cursor.All(ctx, &products); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, product := range products {
+		// Produk yang sudah memiliki pergerakan dilewati agar migrasi aman dijalankan ulang
+		count, err := movements.CountDocuments(ctx, bson.M{"product_id": product.ProductID}, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		_, err = movements.InsertOne(ctx, bson.M{
+			"movement_id":     uuid.New().String(),
+			"product_id":      product.ProductID,
+			"product_code":    product.Code,
+			"type":            "opening",
+			"delta":           product.Stock,
+			"resulting_stock": product.Stock,
+			"actor":           "system:migration",
+			"created_at":      now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
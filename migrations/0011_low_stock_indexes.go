@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     11,
+		Description: "create indexes for low stock alerts and sales velocity",
+		Up:          createLowStockIndexes,
+	})
+}
+
+func createLowStockIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("low_stock_alerts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Agregasi penjualan per produk dalam rentang waktu untuk kecepatan penjualan
+	_, err = db.Collection("inventory_movements").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
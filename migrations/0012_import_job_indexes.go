@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     12,
+		Description: "create indexes for product import jobs",
+		Up:          createImportJobIndexes,
+	})
+}
+
+func createImportJobIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("import_jobs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "job_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// Laporan impor lama dihapus otomatis setelah 30 hari
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(30 * 24 * 60 * 60),
+		},
+	})
+	return err
+}
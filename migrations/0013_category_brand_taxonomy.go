@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"be-stepup/package/slug"
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(Migration{
+		Version:     13,
+		Description: "create categories and brands from product strings",
+		Up:          createCategoryBrandTaxonomy,
+	})
+}
+
+func createCategoryBrandTaxonomy(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("categories").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "category_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("brands").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "brand_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("products").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "category_id", Value: 1}}},
+		{Keys: bson.D{{Key: "brand_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := normalizeProductField(ctx, db, "categories", "category", "category_id", "CAT-"); err != nil {
+		return err
+	}
+	return normalizeProductField(ctx, db, "brands", "brand", "brand_id", "BRAND-")
+}
+
+// normalizeProductField mengelompokkan variasi penulisan seperti "Nike", "nike", dan "NIKE " berdasarkan
+// slug, membuat satu entri per slug dengan ejaan yang paling sering dipakai, lalu mengisi referensi
+// dan nama kanonik di produk. Entri yang sudah ada dengan slug yang sama dipakai ulang.
+func normalizeProductField(ctx context.Context, db *mongo.Database, collection, field, idField, idPrefix string) error {
+	cursor, err := db.Collection("products").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{field: bson.M{"$type": "string", "$ne": ""}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var values []struct {
+		Value string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &values); err != nil {
+		return err
+	}
+
+	// Ejaan kanonik per slug: paling banyak dipakai setelah spasi di tepi dibuang,
+	// seri diputus secara urutan huruf
+	nameCounts := map[string]map[string]int64{}
+	rawBySlug := map[string][]string{}
+	for _, v := range values {
+		s := slug.Make(v.Value)
+		if s == "" {
+			continue
+		}
+		rawBySlug[s] = append(rawBySlug[s], v.Value)
+		if nameCounts[s] == nil {
+			nameCounts[s] = map[string]int64{}
+		}
+		nameCounts[s][strings.TrimSpace(v.Value)] += v.Count
+	}
+	canonical := make(map[string]string, len(nameCounts))
+	for s, names := range nameCounts {
+		var best string
+		for name, count := range names {
+			if best == "" || count > names[best] || (count == names[best] && name < best) {
+				best = name
+			}
+		}
+		canonical[s] = best
+	}
+
+	now := time.Now()
+	for s, name := range canonical {
+		var entry bson.M
+		err := db.Collection(collection).FindOneAndUpdate(ctx,
+			bson.M{"slug": s},
+			bson.M{"$setOnInsert": bson.M{
+				idField:       idPrefix + uuid.New().String()[:8],
+				"name":        name,
+				"slug":        s,
+				"created_at":  now,
+				"modified_at": now,
+			}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&entry)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Collection("products").UpdateMany(ctx,
+			bson.M{field: bson.M{"$in": rawBySlug[s]}},
+			bson.M{"$set": bson.M{idField: entry[idField], field: entry["name"]}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     14,
+		Description: "backfill product status and create lifecycle indexes",
+		Up:          createProductStatus,
+	})
+}
+
+func createProductStatus(ctx context.Context, db *mongo.Database) error {
+	products := db.Collection("products")
+
+	// Produk lama selama ini selalu tampil, jadi semuanya dianggap aktif
+	_, err := products.UpdateMany(ctx,
+		bson.M{"$or": bson.A{bson.M{"status": bson.M{"$exists": false}}, bson.M{"status": ""}}},
+		bson.M{"$set": bson.M{"status": "active"}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "deleted_at", Value: 1}}},
+		{Keys: bson.D{{Key: "publish_at", Value: 1}}},
+		{Keys: bson.D{{Key: "unpublish_at", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     15,
+		Description: "backfill version field for optimistic concurrency",
+		Up:          backfillDocumentVersions,
+	})
+}
+
+// backfillDocumentVersions mengisi version 0 agar dokumen lama bisa dicocokkan oleh filter versi
+func backfillDocumentVersions(ctx context.Context, db *mongo.Database) error {
+	for _, name := range []string{"products", "checkout", "payment"} {
+		_, err := db.Collection(name).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 0}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"be-stepup/package/slug"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+)
+
+func init() {
+	register(Migration{
+		Version:     18,
+		Description: "replace promotion category names with category ids",
+		Up:          promotionCategoryIDs,
+	})
+}
+
+// promotionCategoryIDs mengganti nama kategori di promosi dengan category_id yang slug-nya sama,
+// seperti pencocokan nama produk di migrasi 0013. Nama yang tidak punya kategori dilewati dan
+// dicatat di log agar admin bisa memperbaiki promosinya.
+func promotionCategoryIDs(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("categories").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var categories []struct {
+		CategoryID string `bson:"category_id"`
+		Slug       string `bson:"slug"`
+	}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return err
+	}
+	bySlug := make(map[string]string, len(categories))
+	for _, category := range categories {
+		bySlug[category.Slug] = category.CategoryID
+	}
+
+	cursor, err = db.Collection("promotions").Find(ctx, bson.M{"categories": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	var promotions []struct {
+		PromotionID string   `bson:"promotion_id"`
+		Categories  []string `bson:"categories"`
+		CategoryIDs []string `bson:"category_ids"`
+	}
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return err
+	}
+
+	for _, promo := range promotions {
+		ids := promo.CategoryIDs
+		seen := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			seen[id] = true
+		}
+		for _, name := range promo.Categories {
+			id, ok := bySlug[slug.Make(name)]
+			if !ok {
+				log.Printf("Promotion %s: category %q not found, removed from promotion", promo.PromotionID, name)
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		update := bson.M{"$unset": bson.M{"categories": ""}}
+		if len(ids) > 0 {
+			update["$set"] = bson.M{"category_ids": ids}
+		}
+		_, err := db.Collection("promotions").UpdateOne(ctx, bson.M{"promotion_id": promo.PromotionID}, update)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
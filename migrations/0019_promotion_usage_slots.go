@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     19,
+		Description: "number promotion usages per user and make slots unique",
+		Up:          createPromotionUsageSlots,
+	})
+}
+
+// createPromotionUsageSlots memberi nomor jatah pada pemakaian lama per promosi dan user sesuai
+// urutan waktunya, lalu memasang unique index (promotion_id, user_id, slot) yang dipakai untuk
+// menegakkan usage_limit_per_user secara atomik. Pemakaian tanpa batas per user tidak punya slot
+// sehingga index dibatasi ke dokumen yang punya field slot.
+func createPromotionUsageSlots(ctx context.Context, db *mongo.Database) error {
+	usages := db.Collection("promotion_usages")
+	cursor, err := usages.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"slot": bson.M{"$exists": false}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"promotion_id": "$promotion_id", "user_id": "$user_id"},
+			"ids": bson.M{"$push": "$_id"},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		for i, id := range group.IDs {
+			_, err := usages.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"slot": i + 1}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = usages.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "slot", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slot": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "checkout_id", Value: 1}}},
+	})
+	return err
+}
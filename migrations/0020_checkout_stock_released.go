@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     20,
+		Description: "mark released stock of cancelled checkouts",
+		Up:          checkoutStockReleased,
+	})
+}
+
+// checkoutStockReleased mengisi stock_released_at untuk checkout Cancelled yang sudah ada. Sebelum
+// migrasi ini tidak ada catatan pengembalian stok, sehingga semuanya dianggap sudah dikembalikan
+// agar job checkout-expiry tidak mengembalikan stoknya untuk kedua kali. Index mendukung pencarian
+// checkout Cancelled yang pengembaliannya masih perlu diulang.
+func checkoutStockReleased(ctx context.Context, db *mongo.Database) error {
+	checkouts := db.Collection("checkout")
+	_, err := checkouts.UpdateMany(ctx,
+		bson.M{"status": "Cancelled", "stock_released_at": bson.M{"$exists": false}},
+		[]bson.M{{"$set": bson.M{"stock_released_at": bson.M{"$ifNull": bson.A{"$cancelled_at", "$modified_at"}}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = checkouts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "stock_released_at", Value: 1}, {Key: "cancelled_at", Value: 1}},
+	})
+	return err
+}
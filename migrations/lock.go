@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"time"
+)
+
+// lockCollectionName menyimpan satu dokumen lease agar hanya satu proses yang menjalankan
+// migrasi, dengan pola yang sama seperti job_locks
+const (
+	lockCollectionName = "migrations_lock"
+	lockID             = "migrations"
+)
+
+// Lease diperpanjang setiap leaseRenewInterval; proses yang mati melepaskan lease setelah
+// leaseDuration sehingga replika lain bisa melanjutkan. lockPollInterval adalah jeda
+// sebelum mencoba lagi ketika lease dipegang proses lain.
+var (
+	leaseDuration      = time.Minute
+	leaseRenewInterval = 20 * time.Second
+	lockPollInterval   = 2 * time.Second
+)
+
+// errLeaseLost dikembalikan jika lease diambil alih proses lain di tengah migrasi
+var errLeaseLost = errors.New("migration lock lost to another process")
+
+// lease adalah lease migrasi yang sedang dipegang proses ini
+type lease struct {
+	db    *mongo.Database
+	owner string
+	stop  context.CancelFunc
+	lost  chan struct{}
+}
+
+func newLockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "stepup"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+}
+
+// acquireLock menunggu sampai lease migrasi bisa dipegang atau ctx dibatalkan. Lease
+// diperpanjang di latar belakang sampai release dipanggil; ctx yang dikembalikan dibatalkan
+// jika lease hilang agar migrasi berhenti sebelum bertabrakan dengan pemegang baru.
+func acquireLock(ctx context.Context, db *mongo.Database) (*lease, context.Context, error) {
+	owner := newLockOwner()
+	waiting := false
+	for {
+		acquired, err := tryLock(ctx, db, owner, time.Now())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if !waiting {
+			log.Printf("Migrations are running in another process, waiting for %s", lockCollectionName)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("failed to acquire migration lock: %w", ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	l := &lease{db: db, owner: owner, stop: stop, lost: make(chan struct{})}
+	go l.renew(runCtx)
+	return l, runCtx, nil
+}
+
+// tryLock mengambil lease jika belum ada pemegang, lease sebelumnya sudah habis, atau owner
+// sendiri yang memegangnya
+func tryLock(ctx context.Context, db *mongo.Database, owner string, now time.Time) (bool, error) {
+	_, err := db.Collection(lockCollectionName).UpdateOne(ctx,
+		bson.M{
+			"_id": lockID,
+			"$or": bson.A{
+				bson.M{"owner": owner},
+				bson.M{"locked_until": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"owner":        owner,
+			"locked_until": now.Add(leaseDuration),
+			"acquired_at":  now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// Filter tidak cocok karena lease dipegang proses lain, sehingga upsert bentrok dengan _id yang sudah ada
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// renew memperpanjang lease sampai ctx selesai dan membatalkan migrasi jika lease hilang
+func (l *lease) renew(ctx context.Context) {
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := l.db.Collection(lockCollectionName).UpdateOne(ctx,
+			bson.M{"_id": lockID, "owner": l.owner},
+			bson.M{"$set": bson.M{"locked_until": time.Now().Add(leaseDuration)}},
+		)
+		if err != nil {
+			// Gagal sementara masih aman selama lease belum habis; dicoba lagi di tick berikutnya
+			if ctx.Err() == nil {
+				log.Printf("Error renewing migration lock: %v\n", err)
+			}
+			continue
+		}
+		if result.MatchedCount == 0 {
+			log.Printf("Migration lock was taken over by another process, stopping")
+			close(l.lost)
+			l.stop()
+			return
+		}
+	}
+}
+
+// release menghentikan perpanjangan dan melepaskan lease agar proses lain tidak perlu menunggu
+// lease habis
+func (l *lease) release(ctx context.Context) {
+	l.stop()
+	_, err := l.db.Collection(lockCollectionName).UpdateOne(context.WithoutCancel(ctx),
+		bson.M{"_id": lockID, "owner": l.owner},
+		bson.M{"$set": bson.M{"locked_until": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Error releasing migration lock: %v\n", err)
+	}
+}
+
+// err mengembalikan errLeaseLost jika lease sudah diambil alih proses lain
+func (l *lease) err() error {
+	select {
+	case <-l.lost:
+		return errLeaseLost
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,214 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"sort"
+	"time"
+)
+
+// collectionName adalah koleksi yang mencatat versi migrasi yang sudah dijalankan
+const collectionName = "migrations"
+
+// Migration adalah satu langkah perubahan skema atau data yang berversi
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status menggambarkan apakah sebuah migrasi sudah diterapkan
+type Status struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	Applied     bool      `json:"applied"`
+	AppliedAt   time.Time `json:"applied_at,omitempty"`
+}
+
+// Status record migrasi; record lama tanpa state dianggap sudah diterapkan
+const (
+	stateRunning = "running"
+	stateApplied = "applied"
+)
+
+type record struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	State       string    `bson:"state,omitempty"`
+	Owner       string    `bson:"owner,omitempty"` // Pemegang lease yang sedang menjalankan migrasi ini
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+var registry []Migration
+
+// register menambahkan migrasi ke daftar; dipanggil dari init() setiap file migrasi
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All mengembalikan semua migrasi terurut berdasarkan versi
+func All() []Migration {
+	all := make([]Migration, len(registry))
+	copy(all, registry)
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all
+}
+
+func applied(ctx context.Context, db *mongo.Database) (map[int]record, error) {
+	cursor, err := db.Collection(collectionName).Find(ctx, bson.M{"state": bson.M{"$in": bson.A{nil, stateApplied}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]record, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// List mengembalikan status setiap migrasi yang terdaftar
+func List(ctx context.Context, db *mongo.Database) ([]Status, error) {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	var statuses []Status
+	for _, m := range All() {
+		r, ok := done[m.Version]
+		statuses = append(statuses, Status{
+			Version:     m.Version,
+			Description: m.Description,
+			Applied:     ok,
+			AppliedAt:   r.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Run menjalankan semua migrasi yang belum diterapkan secara berurutan.
+// Hanya satu proses yang menjalankan migrasi pada satu waktu lewat lease di koleksi
+// migrations_lock; proses lain menunggu lalu melewati versi yang sudah diterapkan.
+// Proses berhenti pada migrasi pertama yang gagal sehingga versi berikutnya tidak dijalankan.
+func Run(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(collectionName)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prepare migrations collection: %w", err)
+	}
+
+	lock, runCtx, err := acquireLock(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer lock.release(ctx)
+
+	done, err := applied(runCtx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, m := range All() {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		if err := apply(runCtx, db, m, lock.owner); err != nil {
+			if lost := lock.err(); lost != nil {
+				return fmt.Errorf("migration %04d (%s) interrupted: %w", m.Version, m.Description, lost)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply menandai versi m sebagai running atas nama owner, menjalankan Up, lalu mengubah
+// penandanya menjadi applied hanya jika masih dipegang owner. Penanda running yang tertinggal
+// dari proses yang mati diambil alih karena lease menjamin tidak ada proses lain yang berjalan;
+// unique index pada version mencegah versi yang sudah applied dijalankan ulang.
+func apply(ctx context.Context, db *mongo.Database, m Migration, owner string) error {
+	collection := db.Collection(collectionName)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"version": m.Version, "state": stateRunning},
+		bson.M{"$set": bson.M{
+			"description": m.Description,
+			"state":       stateRunning,
+			"owner":       owner,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		log.Printf("Migration %04d was already applied by another process", m.Version)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim migration %04d: %w", m.Version, err)
+	}
+
+	log.Printf("Applying migration %04d: %s", m.Version, m.Description)
+	if err := m.Up(ctx, db); err != nil {
+		// Penanda dihapus agar versi ini dicoba lagi pada run berikutnya
+		_, cleanupErr := collection.DeleteOne(context.WithoutCancel(ctx),
+			bson.M{"version": m.Version, "state": stateRunning, "owner": owner})
+		if cleanupErr != nil {
+			log.Printf("Error clearing claim of migration %04d: %v\n", m.Version, cleanupErr)
+		}
+		return fmt.Errorf("migration %04d (%s) failed: %w", m.Version, m.Description, err)
+	}
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"version": m.Version, "state": stateRunning, "owner": owner},
+		bson.M{
+			"$set":   bson.M{"state": stateApplied, "applied_at": time.Now()},
+			"$unset": bson.M{"owner": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %04d: %w", m.Version, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("failed to record migration %04d: %w", m.Version, errLeaseLost)
+	}
+	return nil
+}
+
+// ensureCollection membuat koleksi jika belum ada, dibutuhkan sebelum collMod
+func ensureCollection(ctx context.Context, db *mongo.Database, name string) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+	return db.CreateCollection(ctx, name)
+}
+
+// setValidator memasang JSON schema validator pada koleksi.
+// validationLevel "moderate" membiarkan dokumen lama yang belum valid tetap bisa diperbarui.
+func setValidator(ctx context.Context, db *mongo.Database, name string, schema bson.M) error {
+	if err := ensureCollection(ctx, db, name); err != nil {
+		return err
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+}
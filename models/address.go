@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Address adalah alamat pengiriman terstruktur
+type Address struct {
+	Street     string `bson:"street" json:"street" validate:"required,min=5,max=200"`           // Nama jalan, nomor rumah, RT/RW
+	District   string `bson:"district" json:"district" validate:"required,max=100"`             // Kecamatan
+	City       string `bson:"city" json:"city" validate:"required,max=100"`                     // Kota/kabupaten
+	CityID     string `bson:"city_id,omitempty" json:"city_id,omitempty"`                       // ID kota pada penyedia ongkir (misalnya RajaOngkir)
+	Province   string `bson:"province" json:"province" validate:"required,max=100"`             // Provinsi
+	PostalCode string `bson:"postal_code" json:"postal_code" validate:"required,numeric,len=5"` // Kode pos
+}
+
+// String menggabungkan alamat menjadi satu baris untuk tampilan dan ekspor
+func (a Address) String() string {
+	parts := []string{a.Street, a.District, a.City, a.Province, a.PostalCode}
+	var filled []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			filled = append(filled, part)
+		}
+	}
+	return strings.Join(filled, ", ")
+}
+
+// SavedAddress adalah alamat tersimpan di buku alamat user
+type SavedAddress struct {
+	AddressID     string `bson:"address_id" json:"address_id"`
+	Label         string `bson:"label" json:"label" validate:"required,max=30"`                      // Misalnya "Rumah" atau "Kantor"
+	RecipientName string `bson:"recipient_name" json:"recipient_name" validate:"required,max=100"`   // Nama penerima paket
+	PhoneNumber   string `bson:"phone_number" json:"phone_number" validate:"required,min=10,max=15"` // Nomor telepon penerima
+	Address       `bson:",inline" json:",inline"`
+	IsDefault     bool      `bson:"is_default" json:"is_default"` // Alamat utama yang dipakai saat checkout
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	ModifiedAt    time.Time `bson:"modified_at" json:"modified_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Hasil tindakan yang dicatat di audit
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Tindakan sensitif yang dicatat di audit
+const (
+	AuditLogin          = "auth.login"
+	AuditUserCreate     = "user.create"
+	AuditUserRole       = "user.role_change"
+	AuditUserStatus     = "user.status_change"
+	AuditCheckoutStatus = "checkout.status_change"
+	AuditPaymentStatus  = "payment.status_change"
+)
+
+// AuditEvent mencatat satu tindakan sensitif beserta pelaku dan asal request-nya. Koleksi
+// audit_events hanya ditambah, tidak pernah diubah; event dihapus otomatis setelah ExpiresAt.
+type AuditEvent struct {
+	EventID    string                 `bson:"event_id" json:"event_id"`
+	Actor      string                 `bson:"actor" json:"actor"` // userID, "anonymous", atau "system:<nama>"
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"target_type" json:"target_type"` // Misalnya "user", "checkout", "payment"
+	TargetID   string                 `bson:"target_id" json:"target_id"`
+	Outcome    string                 `bson:"outcome" json:"outcome"`
+	IP         string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent  string                 `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	RequestID  string                 `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Details    map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
+	ExpiresAt  time.Time              `bson:"expires_at" json:"-"`
+}
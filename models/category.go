@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Category adalah kategori produk yang bisa bertingkat melalui ParentID
+type Category struct {
+	CategoryID string    `bson:"category_id" json:"category_id"`
+	Name       string    `bson:"name" json:"name"`
+	Slug       string    `bson:"slug" json:"slug"`                               // Unik di semua kategori, dipakai di URL
+	ParentID   string    `bson:"parent_id,omitempty" json:"parent_id,omitempty"` // Kosong untuk kategori teratas
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	ModifiedAt time.Time `bson:"modified_at" json:"modified_at"`
+}
+
+// CategoryRequest adalah body untuk membuat atau mengubah kategori; slug dibuat dari nama jika kosong
+type CategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Slug     string `json:"slug" validate:"omitempty,max=120"`
+	ParentID string `json:"parent_id"`
+}
+
+// Brand adalah merek produk
+type Brand struct {
+	BrandID    string    `bson:"brand_id" json:"brand_id"`
+	Name       string    `bson:"name" json:"name"`
+	Slug       string    `bson:"slug" json:"slug"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	ModifiedAt time.Time `bson:"modified_at" json:"modified_at"`
+}
+
+// BrandRequest adalah body untuk membuat atau mengubah merek; slug dibuat dari nama jika kosong
+type BrandRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Slug string `json:"slug" validate:"omitempty,max=120"`
+}
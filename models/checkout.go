@@ -1,17 +1,65 @@
 package models
 
-import "time"
+import (
+	"be-stepup/package/money"
+	"time"
+)
+
+// Status checkout yang dikenali sistem
+const (
+	CheckoutStatusPending   = "Pending"
+	CheckoutStatusCompleted = "Completed"
+	CheckoutStatusCancelled = "Cancelled"
+)
+
+// Alasan pembatalan checkout
+const (
+	CheckoutCancelPaymentExpired = "payment_expired" // Tidak dibayar sampai batas waktu
+	CheckoutCancelAdmin          = "cancelled_by_admin"
+)
+
+// checkoutTransitions adalah perubahan status yang diizinkan. Completed dan Cancelled adalah
+// status akhir: stok checkout yang dibatalkan sudah dikembalikan sehingga tidak boleh aktif lagi.
+var checkoutTransitions = map[string][]string{
+	CheckoutStatusPending: {CheckoutStatusCompleted, CheckoutStatusCancelled},
+}
 
 // Checkout represents the structure of the checkout process
 type Checkout struct {
-	CheckoutID  string     `bson:"checkout_id" json:"checkout_id"`   // Unique identifier for the checkout
-	UserID      string     `bson:"user_id" json:"user_id"`           // Reference to the User
-	UserName    string     `bson:"user_name" json:"user_name"`       // Name of the user
-	Items       []CartItem `bson:"items" json:"items"`               // List of items to be purchased
-	TotalPrice  float64    `bson:"total_price" json:"total_price"`   // Total price of the checkout
-	Address     string     `bson:"address" json:"address"`           // Address for delivery
-	PhoneNumber string     `bson:"phone_number" json:"phone_number"` // Phone number of the user
-	Status      string     `bson:"status" json:"status"`             // Status of the checkout (e.g., "Pending", "Completed")
-	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`     // Timestamp when the checkout was created
-	ModifiedAt  time.Time  `bson:"modified_at" json:"modified_at"`   // Timestamp when the checkout was last updated
+	CheckoutID       string            `bson:"checkout_id" json:"checkout_id"`                                 // Unique identifier for the checkout
+	UserID           string            `bson:"user_id" json:"user_id"`                                         // Reference to the User
+	UserName         string            `bson:"user_name" json:"user_name"`                                     // Name of the user
+	Items            []CartItem        `bson:"items" json:"items"`                                             // List of items to be purchased
+	Subtotal         money.Money       `bson:"subtotal" json:"subtotal"`                                       // Sum of item prices before discounts
+	Discount         money.Money       `bson:"discount" json:"discount"`                                       // Total discount from promotions
+	Discounts        []AppliedDiscount `bson:"discounts,omitempty" json:"discounts,omitempty"`                 // Breakdown of applied promotions
+	VoucherCode      string            `bson:"voucher_code,omitempty" json:"voucher_code,omitempty"`           // Voucher code entered by the user
+	TotalPrice       money.Money       `bson:"total_price" json:"total_price"`                                 // Total price of the checkout
+	Address          string            `bson:"address" json:"address"`                                         // Address for delivery as a single line
+	ShippingAddress  Address           `bson:"shipping_address" json:"shipping_address"`                       // Structured address for delivery
+	RecipientName    string            `bson:"recipient_name" json:"recipient_name"`                           // Name of the person receiving the package
+	Courier          string            `bson:"courier" json:"courier"`                                         // Courier code (e.g., "jne")
+	CourierService   string            `bson:"courier_service" json:"courier_service"`                         // Courier service (e.g., "REG")
+	WeightGrams      int               `bson:"weight_grams" json:"weight_grams"`                               // Total shipping weight
+	ShippingFee      money.Money       `bson:"shipping_fee" json:"shipping_fee"`                               // Shipping fee quoted by the rate provider
+	ShippingDiscount money.Money       `bson:"shipping_discount" json:"shipping_discount"`                     // Shipping fee waived by a free shipping promotion
+	PhoneNumber      string            `bson:"phone_number" json:"phone_number"`                               // Phone number of the user
+	Status           string            `bson:"status" json:"status"`                                           // Status of the checkout (e.g., "Pending", "Completed")
+	PaymentDeadline  time.Time         `bson:"payment_deadline,omitempty" json:"payment_deadline,omitempty"`   // Unpaid checkout is cancelled after this time
+	CancelReason     string            `bson:"cancel_reason,omitempty" json:"cancel_reason,omitempty"`         // Why the checkout was cancelled
+	CancelledAt      *time.Time        `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`           // Timestamp when the checkout was cancelled
+	StockReleasedAt  *time.Time        `bson:"stock_released_at,omitempty" json:"stock_released_at,omitempty"` // Timestamp when stock and promotion quota of a cancelled checkout were returned
+	CreatedAt        time.Time         `bson:"created_at" json:"created_at"`                                   // Timestamp when the checkout was created
+	ModifiedAt       time.Time         `bson:"modified_at" json:"modified_at"`                                 // Timestamp when the checkout was last updated
+	Version          int64             `bson:"version" json:"version"`                                         // Incremented on every status change, exposed as the ETag
+}
+
+// CanTransitionTo memeriksa apakah status checkout boleh diubah menjadi status
+func (c Checkout) CanTransitionTo(status string) bool {
+	for _, next := range checkoutTransitions[c.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Status job impor produk
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportRowError berisi kesalahan validasi satu baris file impor
+type ImportRowError struct {
+	Line   int      `bson:"line" json:"line"` // Nomor baris di file; header adalah baris 1
+	Code   string   `bson:"code,omitempty" json:"code,omitempty"`
+	Errors []string `bson:"errors" json:"errors"`
+}
+
+// ImportJob mencatat progres impor katalog produk yang berjalan di latar belakang.
+// Dokumen disimpan di database agar progres bisa dibaca dari replika mana pun.
+type ImportJob struct {
+	JobID         string           `bson:"job_id" json:"job_id"`
+	Status        string           `bson:"status" json:"status"`
+	DryRun        bool             `bson:"dry_run" json:"dry_run"` // Hanya validasi, tidak ada produk yang disimpan
+	FileName      string           `bson:"file_name" json:"file_name"`
+	Format        string           `bson:"format" json:"format"`
+	Actor         string           `bson:"actor" json:"actor"`
+	TotalRows     int              `bson:"total_rows" json:"total_rows"`
+	ProcessedRows int              `bson:"processed_rows" json:"processed_rows"`
+	Created       int              `bson:"created" json:"created"` // Produk baru (atau yang akan dibuat saat dry run)
+	Updated       int              `bson:"updated" json:"updated"` // Produk lama yang diperbarui berdasarkan kode
+	Failed        int              `bson:"failed" json:"failed"`
+	RowErrors     []ImportRowError `bson:"row_errors" json:"row_errors"`
+	Error         string           `bson:"error,omitempty" json:"error,omitempty"` // Kesalahan yang menghentikan seluruh job
+	CreatedAt     time.Time        `bson:"created_at" json:"created_at"`
+	StartedAt     *time.Time       `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt    *time.Time       `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Jenis pergerakan stok
+const (
+	MovementOpening      = "opening"      // Saldo awal produk yang sudah ada sebelum ledger dibuat
+	MovementSale         = "sale"         // Stok berkurang karena checkout
+	MovementRestock      = "restock"      // Barang masuk dari pemasok atau stok awal produk baru
+	MovementAdjustment   = "adjustment"   // Koreksi manual, misalnya hasil stock opname
+	MovementReturn       = "return"       // Barang dikembalikan pembeli
+	MovementCancellation = "cancellation" // Stok kembali karena checkout dibatalkan
+)
+
+// InventoryMovement mencatat satu perubahan stok produk. Koleksi inventory_movements hanya
+// ditambah, tidak pernah diubah, sehingga jumlah delta per produk harus sama dengan stoknya.
+type InventoryMovement struct {
+	MovementID     string    `bson:"movement_id" json:"movement_id"`
+	ProductID      string    `bson:"product_id" json:"product_id"`
+	ProductCode    string    `bson:"product_code" json:"product_code"`
+	Type           string    `bson:"type" json:"type"`
+	Delta          int       `bson:"delta" json:"delta"`                     // Perubahan stok, negatif jika berkurang
+	ResultingStock int       `bson:"resulting_stock" json:"resulting_stock"` // Stok setelah perubahan
+	Actor          string    `bson:"actor" json:"actor"`                     // userID, atau "system:<job>" untuk job latar belakang
+	ReferenceID    string    `bson:"reference_id,omitempty" json:"reference_id,omitempty"`
+	Note           string    `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// CrossesBelow memeriksa apakah pergerakan ini membuat stok turun dari atas threshold ke threshold atau di bawahnya
+func (m InventoryMovement) CrossesBelow(threshold int) bool {
+	previous := m.ResultingStock - m.Delta
+	return threshold > 0 && previous > threshold && m.ResultingStock <= threshold
+}
+
+// InventoryAdjustmentRequest adalah body untuk penyesuaian stok manual oleh admin
+type InventoryAdjustmentRequest struct {
+	ProductID   string `json:"product_id" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=restock adjustment return"`
+	Delta       int    `json:"delta" validate:"required"`
+	ReferenceID string `json:"reference_id" validate:"max=100"`
+	Note        string `json:"note" validate:"max=500"`
+}
+
+// InventoryBalance adalah ringkasan ledger satu produk untuk rekonsiliasi
+type InventoryBalance struct {
+	ProductID          string `bson:"_id" json:"product_id"`
+	Total              int    `bson:"total" json:"total"`
+	LastResultingStock int    `bson:"last_resulting_stock" json:"last_resulting_stock"`
+	Movements          int64  `bson:"movements" json:"movements"`
+}
+
+// LowStockAlert dicatat setiap kali stok produk turun melewati batas stok menipis
+type LowStockAlert struct {
+	AlertID     string    `bson:"alert_id" json:"alert_id"`
+	ProductID   string    `bson:"product_id" json:"product_id"`
+	ProductCode string    `bson:"product_code" json:"product_code"`
+	ProductName string    `bson:"product_name" json:"product_name"`
+	Threshold   int       `bson:"threshold" json:"threshold"`
+	Stock       int       `bson:"stock" json:"stock"`
+	MovementID  string    `bson:"movement_id" json:"movement_id"` // Pergerakan stok yang memicu peringatan
+	Actor       string    `bson:"actor" json:"actor"`
+	ReferenceID string    `bson:"reference_id,omitempty" json:"reference_id,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// ProductSales adalah jumlah unit terjual satu produk dalam rentang waktu
+type ProductSales struct {
+	ProductID string `bson:"_id" json:"product_id"`
+	Units     int    `bson:"units" json:"units"`
+}
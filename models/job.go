@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// JobState menyimpan lease dan hasil run terakhir sebuah job latar belakang.
+// Dokumen ini dipakai bersama oleh semua replika server.
+type JobState struct {
+	Name           string           `bson:"_id" json:"name"`
+	Interval       string           `bson:"interval" json:"interval"`
+	Owner          string           `bson:"owner" json:"owner"`               // Replika yang memegang lease
+	LockedUntil    time.Time        `bson:"locked_until" json:"locked_until"` // Replika lain baru boleh menjalankan job setelah waktu ini
+	LastStartedAt  time.Time        `bson:"last_started_at,omitempty" json:"last_started_at,omitempty"`
+	LastFinishedAt time.Time        `bson:"last_finished_at,omitempty" json:"last_finished_at,omitempty"`
+	LastDurationMs int64            `bson:"last_duration_ms" json:"last_duration_ms"`
+	LastError      string           `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastResult     map[string]int64 `bson:"last_result,omitempty" json:"last_result,omitempty"`
+	Runs           int64            `bson:"runs" json:"runs"`
+}
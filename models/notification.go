@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Status pengiriman notifikasi
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+)
+
+// Jenis notifikasi
+const (
+	NotificationCartReminder = "cart_reminder"
+	NotificationLowStock     = "low_stock"
+)
+
+// Notification adalah pesan untuk user yang menunggu dikirim oleh kanal notifikasi (email, push, dsb.)
+type Notification struct {
+	NotificationID string                 `bson:"notification_id" json:"notification_id"`
+	UserID         string                 `bson:"user_id" json:"user_id"`
+	Type           string                 `bson:"type" json:"type"`
+	Title          string                 `bson:"title" json:"title"`
+	Message        string                 `bson:"message" json:"message"`
+	Data           map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`
+	Status         string                 `bson:"status" json:"status"`
+	CreatedAt      time.Time              `bson:"created_at" json:"created_at"`
+	SentAt         *time.Time             `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
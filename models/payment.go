@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// Status pembayaran; bukti yang ditolak tidak lagi menahan checkout dari pembatalan otomatis
+const (
+	PaymentStatusPending  = "Pending"
+	PaymentStatusVerified = "Verified"
+	PaymentStatusRejected = "Rejected"
+)
+
 // Payment represents the structure of a payment
 type Payment struct {
 	PaymentID     string    `bson:"payment_id" json:"payment_id"`         // Unique identifier for the payment
@@ -11,4 +18,5 @@ type Payment struct {
 	PaymentStatus string    `bson:"payment_status" json:"payment_status"` // Status of the payment (e.g., "Pending", "Verified")
 	CreatedAt     time.Time `bson:"created_at" json:"created_at"`         // Timestamp when the payment was created
 	ModifiedAt    time.Time `bson:"modified_at" json:"modified_at"`       // Timestamp when the payment was last updated
+	Version       int64     `bson:"version" json:"version"`               // Incremented on every status change, exposed as the ETag
 }
@@ -1,18 +1,110 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"be-stepup/package/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Status produk; hanya produk aktif yang tampil di katalog publik dan bisa dibeli
+const (
+	ProductDraft    = "draft"
+	ProductActive   = "active"
+	ProductArchived = "archived"
+)
 
 // Product defines the structure of product data
 type Product struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ProductID   string             `bson:"product_id" json:"product_id"`
-	Code        string             `bson:"code" json:"code"`
-	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
-	Brand       string             `bson:"brand" json:"brand"`
-	Category    string             `bson:"category" json:"category"`
-	Color       string             `bson:"color" json:"color"`
-	Price       float64            `bson:"price" json:"price"`
-	Stock       int                `bson:"stock" json:"stock"`
-	ImageURL    string             `bson:"image_url" json:"image_url"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID         string             `bson:"product_id" json:"product_id"`
+	Code              string             `bson:"code" json:"code"`
+	Name              string             `bson:"name" json:"name"`
+	Description       string             `bson:"description" json:"description"`
+	BrandID           string             `bson:"brand_id" json:"brand_id"`
+	Brand             string             `bson:"brand" json:"brand"` // Nama merek, disalin dari koleksi brands
+	CategoryID        string             `bson:"category_id" json:"category_id"`
+	Category          string             `bson:"category" json:"category"` // Nama kategori, disalin dari koleksi categories
+	Color             string             `bson:"color" json:"color"`
+	Price             money.Money        `bson:"price" json:"price"`
+	Stock             int                `bson:"stock" json:"stock"`
+	WeightGrams       int                `bson:"weight_grams" json:"weight_grams"`
+	MaxOrderQuantity  int                `bson:"max_order_quantity" json:"max_order_quantity"`   // Batas pembelian per pesanan; 0 memakai batas bawaan
+	LowStockThreshold int                `bson:"low_stock_threshold" json:"low_stock_threshold"` // Peringatan dikirim saat stok turun ke batas ini; 0 berarti tanpa peringatan
+	ImageURL          string             `bson:"image_url" json:"image_url"`
+	RatingAverage     float64            `bson:"rating_average" json:"rating_average"` // Rata-rata rating dari ulasan yang disetujui
+	RatingCount       int                `bson:"rating_count" json:"rating_count"`     // Jumlah ulasan yang disetujui
+	Status            string             `bson:"status" json:"status"`
+	PublishAt         *time.Time         `bson:"publish_at,omitempty" json:"publish_at,omitempty"`     // Draft otomatis aktif pada waktu ini
+	UnpublishAt       *time.Time         `bson:"unpublish_at,omitempty" json:"unpublish_at,omitempty"` // Produk aktif otomatis diarsipkan pada waktu ini
+	DeletedAt         *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`     // Diisi saat produk dihapus; produk bisa dipulihkan
+	Version           int64              `bson:"version" json:"version"`                               // Naik setiap kali data produk diubah admin; perubahan stok karena transaksi tidak menaikkan versi
+}
+
+// Available bernilai true jika produk tampil di katalog publik pada waktu now. Jadwal dihitung
+// langsung agar produk tidak menunggu job jadwal untuk tampil atau disembunyikan.
+func (p Product) Available(now time.Time) bool {
+	if p.DeletedAt != nil {
+		return false
+	}
+	if p.UnpublishAt != nil && !p.UnpublishAt.After(now) {
+		return false
+	}
+	switch p.Status {
+	case ProductActive:
+		return true
+	case ProductDraft:
+		return p.PublishAt != nil && !p.PublishAt.After(now)
+	}
+	return false
+}
+
+// ProductStatusRequest adalah body untuk mengubah status dan jadwal tayang produk
+type ProductStatusRequest struct {
+	Status      string     `json:"status" validate:"required,oneof=draft active archived"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Version     *int64     `json:"version"` // Alternatif header If-Match
+}
+
+// ProductCreateRequest adalah body untuk membuat produk. ID, kode, versi, rating, dan status
+// hapus selalu diisi server sehingga tidak ada di sini.
+type ProductCreateRequest struct {
+	Name              string      `json:"name"`
+	Description       string      `json:"description"`
+	BrandID           string      `json:"brand_id"`
+	Brand             string      `json:"brand"`
+	CategoryID        string      `json:"category_id"`
+	Category          string      `json:"category"`
+	Color             string      `json:"color"`
+	Price             money.Money `json:"price"`
+	Stock             int         `json:"stock"`
+	WeightGrams       int         `json:"weight_grams"`
+	MaxOrderQuantity  int         `json:"max_order_quantity"`
+	LowStockThreshold int         `json:"low_stock_threshold"`
+	Status            string      `json:"status"`
+	PublishAt         *time.Time  `json:"publish_at"`
+	UnpublishAt       *time.Time  `json:"unpublish_at"`
+}
+
+// Product mengubah request menjadi produk baru dengan ID dan kode yang diberikan
+func (r ProductCreateRequest) Product(productID, code string) Product {
+	return Product{
+		ProductID:         productID,
+		Code:              code,
+		Name:              r.Name,
+		Description:       r.Description,
+		BrandID:           r.BrandID,
+		Brand:             r.Brand,
+		CategoryID:        r.CategoryID,
+		Category:          r.Category,
+		Color:             r.Color,
+		Price:             r.Price,
+		Stock:             r.Stock,
+		WeightGrams:       r.WeightGrams,
+		MaxOrderQuantity:  r.MaxOrderQuantity,
+		LowStockThreshold: r.LowStockThreshold,
+		Status:            r.Status,
+		PublishAt:         r.PublishAt,
+		UnpublishAt:       r.UnpublishAt,
+	}
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Jenis perubahan produk yang dicatat di riwayat
+const (
+	ProductAuditCreate  = "create"
+	ProductAuditUpdate  = "update"
+	ProductAuditStatus  = "status_change" // Status atau jadwal tayang
+	ProductAuditStock   = "stock_change"  // Perubahan stok, termasuk penjualan dan pembatalan checkout
+	ProductAuditDelete  = "delete"
+	ProductAuditRestore = "restore"
+)
+
+// FieldChange adalah nilai satu field sebelum dan sesudah perubahan; Before nil saat produk dibuat
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// ProductAudit mencatat satu perubahan produk beserta pelakunya. Koleksi product_audits hanya
+// ditambah, tidak pernah diubah.
+type ProductAudit struct {
+	AuditID     string        `bson:"audit_id" json:"audit_id"`
+	ProductID   string        `bson:"product_id" json:"product_id"`
+	ProductCode string        `bson:"product_code" json:"product_code"`
+	Action      string        `bson:"action" json:"action"`
+	Actor       string        `bson:"actor" json:"actor"` // userID, atau "system:<nama>" untuk proses otomatis
+	Changes     []FieldChange `bson:"changes" json:"changes"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+
+	// Diisi untuk stock_change: jenis pergerakan ledger dan referensinya, misalnya checkout_id
+	MovementType string `bson:"movement_type,omitempty" json:"movement_type,omitempty"`
+	ReferenceID  string `bson:"reference_id,omitempty" json:"reference_id,omitempty"`
+}
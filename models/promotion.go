@@ -0,0 +1,70 @@
+package models
+
+import (
+	"be-stepup/package/money"
+	"time"
+)
+
+// Jenis promosi
+const (
+	PromotionPercentage   = "percentage"
+	PromotionFixedAmount  = "fixed_amount"
+	PromotionFreeShipping = "free_shipping"
+)
+
+// Cakupan promosi
+const (
+	PromotionScopeOrder    = "order"
+	PromotionScopeProduct  = "product"
+	PromotionScopeCategory = "category"
+)
+
+// Promotion adalah voucher (punya Code) atau diskon otomatis (Code kosong)
+type Promotion struct {
+	PromotionID       string      `bson:"promotion_id" json:"promotion_id"`                                          // Unique identifier for the promotion
+	Name              string      `bson:"name" json:"name" validate:"required,max=100"`                              // Display name
+	Code              string      `bson:"code,omitempty" json:"code,omitempty" validate:"omitempty,alphanum,max=32"` // Voucher code, empty for automatic discounts
+	Type              string      `bson:"type" json:"type" validate:"required,oneof=percentage fixed_amount free_shipping"`
+	Scope             string      `bson:"scope" json:"scope" validate:"required,oneof=order product category"`
+	Percentage        int         `bson:"percentage,omitempty" json:"percentage,omitempty" validate:"min=0,max=100"` // For percentage discounts
+	Amount            money.Money `bson:"amount" json:"amount"`                                                      // For fixed amount discounts
+	MaxDiscount       money.Money `bson:"max_discount" json:"max_discount"`                                          // Cap for percentage discounts, zero means no cap
+	ProductIDs        []string    `bson:"product_ids,omitempty" json:"product_ids,omitempty"`                        // Products covered when scope is product
+	CategoryIDs       []string    `bson:"category_ids,omitempty" json:"category_ids,omitempty"`                      // Categories covered, including their subcategories, when scope is category
+	MinSpend          money.Money `bson:"min_spend" json:"min_spend"`                                                // Minimum cart subtotal
+	UsageLimit        int         `bson:"usage_limit" json:"usage_limit" validate:"min=0"`                           // Global limit, 0 means unlimited
+	UsageLimitPerUser int         `bson:"usage_limit_per_user" json:"usage_limit_per_user" validate:"min=0"`         // Per-user limit, 0 means unlimited
+	UsedCount         int         `bson:"used_count" json:"used_count"`                                              // Number of checkouts that used the promotion
+	StartsAt          time.Time   `bson:"starts_at" json:"starts_at"`                                                // Start of the validity window
+	EndsAt            time.Time   `bson:"ends_at" json:"ends_at"`                                                    // End of the validity window, zero means no end
+	Active            bool        `bson:"active" json:"active"`                                                      // Inactive promotions are never applied
+	CreatedAt         time.Time   `bson:"created_at" json:"created_at"`
+	ModifiedAt        time.Time   `bson:"modified_at" json:"modified_at"`
+}
+
+// ActiveAt bernilai true jika promosi aktif dan berada dalam masa berlaku
+func (p Promotion) ActiveAt(now time.Time) bool {
+	if !p.Active || now.Before(p.StartsAt) {
+		return false
+	}
+	return p.EndsAt.IsZero() || now.Before(p.EndsAt)
+}
+
+// AppliedDiscount adalah rincian diskon yang diterapkan pada checkout
+type AppliedDiscount struct {
+	PromotionID  string      `bson:"promotion_id" json:"promotion_id"`
+	Name         string      `bson:"name" json:"name"`
+	Code         string      `bson:"code,omitempty" json:"code,omitempty"`
+	Type         string      `bson:"type" json:"type"`
+	Discount     money.Money `bson:"discount" json:"discount"`
+	FreeShipping bool        `bson:"free_shipping" json:"free_shipping"`
+}
+
+// PromotionUsage mencatat pemakaian promosi oleh user pada sebuah checkout
+type PromotionUsage struct {
+	PromotionID string    `bson:"promotion_id" json:"promotion_id"`
+	UserID      string    `bson:"user_id" json:"user_id"`
+	CheckoutID  string    `bson:"checkout_id" json:"checkout_id"`
+	Slot        int       `bson:"slot,omitempty" json:"slot,omitempty"` // Jatah ke-1 sampai usage_limit_per_user; kosong jika tanpa batas per user
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
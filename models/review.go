@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Status moderasi ulasan; hanya ulasan approved yang tampil dan dihitung dalam rating produk
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewHidden   = "hidden"
+)
+
+// Review adalah ulasan dan rating produk dari pembeli terverifikasi
+type Review struct {
+	ReviewID   string    `bson:"review_id" json:"review_id"`
+	ProductID  string    `bson:"product_id" json:"product_id"`
+	UserID     string    `bson:"user_id" json:"user_id"`
+	UserName   string    `bson:"user_name" json:"user_name"`
+	Rating     int       `bson:"rating" json:"rating"` // 1 sampai 5
+	Comment    string    `bson:"comment" json:"comment"`
+	PhotoURLs  []string  `bson:"photo_urls,omitempty" json:"photo_urls,omitempty"`
+	Status     string    `bson:"status" json:"status"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	ModifiedAt time.Time `bson:"modified_at" json:"modified_at"`
+}
+
+// ReviewRequest adalah data ulasan yang dikirim pembeli (JSON atau multipart form)
+type ReviewRequest struct {
+	Rating  int    `json:"rating" form:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" form:"comment" validate:"max=2000"`
+}
@@ -2,17 +2,61 @@ package models
 
 import "time"
 
+// Role yang dikenali sistem; role selalu ditentukan server, bukan oleh klien. Staf boleh
+// memproses checkout, memverifikasi pembayaran, dan memoderasi ulasan; sisanya khusus admin.
+const (
+	RoleUser  = "user"
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// RegisterRequest adalah data yang boleh dikirim klien saat registrasi publik
+type RegisterRequest struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// CreateStaffRequest adalah data untuk membuat akun staff/admin melalui API admin
+type CreateStaffRequest struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Role     string `json:"role" validate:"required,oneof=staff admin"`
+}
+
+// UpdateProfileRequest adalah data profil yang boleh diubah user sendiri
+type UpdateProfileRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	PhoneNumber string `json:"phone_number" validate:"omitempty,min=10,max=15"`
+}
+
+// ChangePasswordRequest mewajibkan password lama sebelum password baru disimpan
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72,nefield=CurrentPassword"`
+}
+
+// DeleteAccountRequest meminta konfirmasi password sebelum akun dihapus
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
 // User represents the structure of a user
 type User struct {
-	UserID    string    `bson:"userid"`
-	Email     string    `bson:"email"`
-	Password  string    `bson:"password"`
-	Role      string    `bson:"role"`
-	Name      string    `bson:"name"`       // Menambahkan field nama
-	CreatedAt time.Time `bson:"created_at"` // Menambahkan field tanggal pembuatan akun
+	UserID      string         `bson:"userid"`
+	Email       string         `bson:"email"`
+	Password    string         `bson:"password" json:"-"` // Hash password tidak pernah dikirim dalam response
+	Role        string         `bson:"role"`
+	Name        string         `bson:"name"`                // Menambahkan field nama
+	PhoneNumber string         `bson:"phone_number"`        // Nomor telepon yang bisa diubah user sendiri
+	AvatarURL   string         `bson:"avatar_url"`          // Foto profil
+	Disabled    bool           `bson:"disabled"`            // Akun yang dinonaktifkan admin tidak bisa login
+	Addresses   []SavedAddress `bson:"addresses,omitempty"` // Buku alamat pengiriman
+	CreatedAt   time.Time      `bson:"created_at"`          // Menambahkan field tanggal pembuatan akun
 }
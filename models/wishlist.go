@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WishlistItem adalah satu produk yang disimpan user untuk dibeli nanti.
+// Setiap pasangan user_id dan product_id hanya boleh muncul sekali.
+type WishlistItem struct {
+	UserID    string    `bson:"user_id" json:"user_id"`       // Reference to the User
+	ProductID string    `bson:"product_id" json:"product_id"` // Reference to the Product
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // Timestamp when the product was wishlisted
+}
+
+// WishlistCount adalah jumlah user yang menyimpan sebuah produk di wishlist
+type WishlistCount struct {
+	ProductID string `bson:"_id" json:"product_id"`
+	Users     int64  `bson:"users" json:"users"`
+}
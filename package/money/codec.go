@@ -0,0 +1,101 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"strings"
+)
+
+// document adalah bentuk Money di MongoDB: {amount: <int64>, currency: "IDR"}
+type document struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// MarshalBSONValue menyimpan Money sebagai embedded document
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(document{Amount: m.Amount, Currency: m.currency()})
+}
+
+// UnmarshalBSONValue membaca embedded document. Angka biasa dari data lama
+// (float64 rupiah sebelum migrasi) dibaca sebagai nominal utama dalam DefaultCurrency.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var doc document
+		if err := raw.Unmarshal(&doc); err != nil {
+			return err
+		}
+		*m = Money{Amount: doc.Amount, Currency: doc.Currency}
+	case bsontype.Double:
+		*m = FromMajor(raw.Double(), DefaultCurrency)
+	case bsontype.Int32:
+		*m = FromMajor(float64(raw.Int32()), DefaultCurrency)
+	case bsontype.Int64:
+		*m = FromMajor(float64(raw.Int64()), DefaultCurrency)
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("money: cannot decode BSON type %s", t)
+	}
+	return nil
+}
+
+// jsonMoney adalah bentuk Money di response API
+type jsonMoney struct {
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Formatted string `json:"formatted,omitempty"`
+}
+
+// MarshalJSON menulis {"amount": 59800000, "currency": "IDR", "formatted": "Rp598.000"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Amount, Currency: m.currency(), Formatted: m.Format()})
+}
+
+// UnmarshalJSON menerima objek {"amount", "currency"} dalam satuan terkecil,
+// atau angka/string nominal utama seperti 598000 atau "598000.50" dalam DefaultCurrency.
+// Mata uang di luar tabel exponents ditolak agar nominal tidak ditafsirkan dengan desimal yang salah.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		var v jsonMoney
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		currency := strings.ToUpper(strings.TrimSpace(v.Currency))
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		if _, ok := exponents[currency]; !ok {
+			return fmt.Errorf("money: unknown currency %q", v.Currency)
+		}
+		*m = Money{Amount: v.Amount, Currency: currency}
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return m.UnmarshalText([]byte(s))
+	default:
+		return m.UnmarshalText(data)
+	}
+}
+
+// UnmarshalText membaca nominal utama dari form atau query, misalnya price=598000
+func (m *Money) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
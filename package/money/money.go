@@ -0,0 +1,251 @@
+// Package money menyimpan nominal uang sebagai bilangan bulat dalam satuan terkecil
+// (misalnya sen untuk IDR) beserta kode mata uang ISO 4217, sehingga penjumlahan
+// total tidak mengalami kesalahan pembulatan seperti float64.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency dipakai untuk nominal lama yang tersimpan tanpa kode mata uang
+const DefaultCurrency = "IDR"
+
+// ErrCurrencyMismatch dikembalikan saat menjumlahkan dua mata uang yang berbeda
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// exponents adalah jumlah digit desimal satuan terkecil per mata uang (ISO 4217)
+var exponents = map[string]int{
+	"IDR": 2,
+	"USD": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"JPY": 0,
+}
+
+// Money adalah nominal dalam satuan terkecil beserta mata uangnya
+type Money struct {
+	Amount   int64  // Nominal dalam satuan terkecil, misalnya Rp598.000 = 59800000 sen
+	Currency string // Kode ISO 4217, misalnya "IDR"
+}
+
+// New membuat Money dari nominal satuan terkecil
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IDR membuat Money rupiah dari nominal rupiah utuh
+func IDR(rupiah int64) Money {
+	return Money{Amount: rupiah * pow10(exponent("IDR")), Currency: "IDR"}
+}
+
+// FromMajor mengubah nominal float (misalnya data lama) ke satuan terkecil dengan pembulatan
+func FromMajor(major float64, currency string) Money {
+	return Money{
+		Amount:   int64(math.Round(major * float64(pow10(exponent(currency))))),
+		Currency: currency,
+	}
+}
+
+// Parse membaca nominal desimal seperti "598000", "598000.50", atau "-5" tanpa melalui float.
+// Hanya satu tanda minus di depan yang diterima; bagian bulat dan desimal harus berupa digit ASCII.
+func Parse(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, fmt.Errorf("money: empty amount")
+	}
+
+	digits, negative := strings.CutPrefix(value, "-")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasPoint && !isDigits(fraction)) {
+		return Money{}, fmt.Errorf("money: invalid amount %q", value)
+	}
+	exp := exponent(currency)
+	if len(fraction) > exp {
+		return Money{}, fmt.Errorf("money: %q has more than %d decimal places", value, exp)
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("money: amount %q out of range", value)
+	}
+	var minor int64
+	if fraction != "" {
+		if minor, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+			return Money{}, fmt.Errorf("money: invalid amount %q", value)
+		}
+	}
+
+	if major > (math.MaxInt64-minor)/pow10(exp) {
+		return Money{}, fmt.Errorf("money: amount %q out of range", value)
+	}
+	amount := major*pow10(exp) + minor
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// isDigits bernilai true jika s tidak kosong dan hanya berisi digit 0-9
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// compatible mengecek mata uang; nilai nol tanpa mata uang cocok dengan mata uang apa pun
+func (m Money) compatible(other Money) (string, bool) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, true
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, true
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, true
+	}
+	return "", false
+}
+
+// Add menjumlahkan dua nominal dengan mata uang yang sama
+func (m Money) Add(other Money) (Money, error) {
+	currency, ok := m.compatible(other)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub mengurangi nominal dengan mata uang yang sama
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(other.Neg())
+}
+
+// Mul mengalikan nominal, misalnya harga satuan dikali kuantitas
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Neg membalik tanda nominal
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Equal bernilai true jika nominal dan mata uang sama
+func (m Money) Equal(other Money) bool {
+	_, ok := m.compatible(other)
+	return ok && m.Amount == other.Amount
+}
+
+// IsZero bernilai true jika nominal nol
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsPositive bernilai true jika nominal lebih dari nol
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// IsNegative bernilai true jika nominal kurang dari nol
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Major mengembalikan nominal dalam satuan utama, hanya untuk tampilan atau ekspor
+func (m Money) Major() float64 {
+	return float64(m.Amount) / float64(pow10(exponent(m.currency())))
+}
+
+// DecimalString mengembalikan nominal satuan utama tanpa simbol, misalnya "598000.00"
+func (m Money) DecimalString() string {
+	exp := exponent(m.currency())
+	abs := m.Amount
+	sign := ""
+	if abs < 0 {
+		abs, sign = -abs, "-"
+	}
+	whole := strconv.FormatInt(abs/pow10(exp), 10)
+	if exp == 0 {
+		return sign + whole
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, whole, exp, abs%pow10(exp))
+}
+
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// Format menampilkan nominal untuk manusia. IDR memakai format Indonesia
+// ("Rp598.000" atau "Rp1.250,50"); mata uang lain memakai "USD 1,250.50".
+func (m Money) Format() string {
+	currency := m.currency()
+	exp := exponent(currency)
+
+	abs := m.Amount
+	sign := ""
+	if abs < 0 {
+		abs, sign = -abs, "-"
+	}
+	whole, fraction := abs/pow10(exp), abs%pow10(exp)
+
+	if currency == "IDR" {
+		out := sign + "Rp" + groupThousands(whole, ".")
+		if fraction != 0 {
+			out += fmt.Sprintf(",%0*d", exp, fraction)
+		}
+		return out
+	}
+
+	out := sign + currency + " " + groupThousands(whole, ",")
+	if exp > 0 {
+		out += fmt.Sprintf(".%0*d", exp, fraction)
+	}
+	return out
+}
+
+// String mengimplementasikan fmt.Stringer
+func (m Money) String() string {
+	return m.Format()
+}
+
+func groupThousands(n int64, separator string) string {
+	digits := strconv.FormatInt(n, 10)
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
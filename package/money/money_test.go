@@ -0,0 +1,206 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "598000", currency: "IDR", want: New(59800000, "IDR")},
+		{value: " 598000.5 ", currency: "IDR", want: New(59800050, "IDR")},
+		{value: "598000.50", currency: "IDR", want: New(59800050, "IDR")},
+		{value: "-5", currency: "IDR", want: New(-500, "IDR")},
+		{value: "0.01", currency: "USD", want: New(1, "USD")},
+		{value: "1500", currency: "JPY", want: New(1500, "JPY")},
+		{value: "9223372036854775.80", currency: "IDR", want: New(922337203685477580, "IDR")},
+		{value: "", currency: "IDR", wantErr: true},
+		{value: "--5", currency: "IDR", wantErr: true},
+		{value: "+5", currency: "IDR", wantErr: true},
+		{value: "1.-5", currency: "IDR", wantErr: true},
+		{value: "1.+5", currency: "IDR", wantErr: true},
+		{value: "-", currency: "IDR", wantErr: true},
+		{value: ".5", currency: "IDR", wantErr: true},
+		{value: "5.", currency: "IDR", wantErr: true},
+		{value: "1.2.3", currency: "IDR", wantErr: true},
+		{value: "1,000", currency: "IDR", wantErr: true},
+		{value: "abc", currency: "IDR", wantErr: true},
+		{value: "1.234", currency: "IDR", wantErr: true},
+		{value: "1.5", currency: "JPY", wantErr: true},
+		{value: "92233720368547758.08", currency: "IDR", wantErr: true},
+		{value: "99999999999999999999", currency: "IDR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse(%q) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		money   Money
+		format  string
+		decimal string
+	}{
+		{IDR(598000), "Rp598.000", "598000.00"},
+		{New(125000050, "IDR"), "Rp1.250.000,50", "1250000.50"},
+		{IDR(-1500), "-Rp1.500", "-1500.00"},
+		{New(-5, "IDR"), "-Rp0,05", "-0.05"},
+		{IDR(0), "Rp0", "0.00"},
+		{New(125050, "USD"), "USD 1,250.50", "1250.50"},
+		{New(1500, "JPY"), "JPY 1,500", "1500"},
+		{New(500, ""), "Rp5", "5.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Format(); got != tt.format {
+			t.Errorf("%#v.Format() = %q, want %q", tt.money, got, tt.format)
+		}
+		if got := tt.money.DecimalString(); got != tt.decimal {
+			t.Errorf("%#v.DecimalString() = %q, want %q", tt.money, got, tt.decimal)
+		}
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	sum, err := IDR(100).Add(IDR(50))
+	if err != nil || sum != IDR(150) {
+		t.Fatalf("Add = %v, %v; want Rp150", sum, err)
+	}
+	diff, err := IDR(100).Sub(IDR(150))
+	if err != nil || diff != IDR(-50) {
+		t.Fatalf("Sub = %v, %v; want -Rp50", diff, err)
+	}
+
+	// Nol tanpa mata uang (nilai awal struct) cocok dengan mata uang apa pun
+	sum, err = Money{}.Add(New(100, "USD"))
+	if err != nil || sum != New(100, "USD") {
+		t.Fatalf("zero Add = %v, %v; want USD 1.00", sum, err)
+	}
+
+	if _, err := IDR(100).Add(New(100, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Add mismatched err = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := IDR(100).Sub(New(100, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Sub mismatched err = %v, want ErrCurrencyMismatch", err)
+	}
+	if IDR(100).Equal(New(10000, "USD")) {
+		t.Fatal("Equal across currencies = true, want false")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(59800050, "IDR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":59800050,"currency":"IDR","formatted":"Rp598.000,50"}`; string(data) != want {
+		t.Fatalf("Marshal = %s, want %s", data, want)
+	}
+
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: `{"amount":59800050,"currency":"IDR"}`, want: New(59800050, "IDR")},
+		{input: `{"amount":100}`, want: New(100, "IDR")},
+		{input: `{"amount":100,"currency":"usd"}`, want: New(100, "USD")},
+		{input: `{"amount":100,"currency":"XYZ"}`, wantErr: true},
+		{input: `{"amount":100,"currency":"rupiah"}`, wantErr: true},
+		{input: `598000`, want: IDR(598000)},
+		{input: `"598000.50"`, want: New(59800050, "IDR")},
+		{input: `null`, want: Money{}},
+		{input: `"--5"`, wantErr: true},
+		{input: `"abc"`, wantErr: true},
+		{input: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %v, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Unmarshal(%s) = %#v, %v; want %#v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestBSON(t *testing.T) {
+	type wrapper struct {
+		Price Money `bson:"price"`
+	}
+
+	data, err := bson.Marshal(wrapper{Price: New(59800050, "IDR")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Price document `bson:"price"`
+	}
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Price != (document{Amount: 59800050, Currency: "IDR"}) {
+		t.Fatalf("stored document = %+v", raw.Price)
+	}
+
+	var decoded wrapper
+	if err := bson.Unmarshal(data, &decoded); err != nil || decoded.Price != New(59800050, "IDR") {
+		t.Fatalf("round trip = %#v, %v", decoded.Price, err)
+	}
+
+	// Data lama menyimpan harga sebagai angka rupiah biasa sebelum migrasi satuan terkecil
+	legacy := []struct {
+		name  string
+		value interface{}
+		want  Money
+	}{
+		{"double", 598000.5, New(59800050, "IDR")},
+		{"int32", int32(598000), IDR(598000)},
+		{"int64", int64(598000), IDR(598000)},
+		{"null", nil, Money{}},
+	}
+	for _, tt := range legacy {
+		data, err := bson.Marshal(bson.M{"price": tt.value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got wrapper
+		if err := bson.Unmarshal(data, &got); err != nil || got.Price != tt.want {
+			t.Errorf("legacy %s = %#v, %v; want %#v", tt.name, got.Price, err, tt.want)
+		}
+	}
+
+	data, err = bson.Marshal(bson.M{"price": "598000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var invalid wrapper
+	if err := bson.Unmarshal(data, &invalid); err == nil {
+		t.Fatalf("decoding string price = %#v, want error", invalid.Price)
+	}
+}
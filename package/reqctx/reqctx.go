@@ -0,0 +1,32 @@
+package reqctx
+
+import "context"
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "requestID"
+	userIDKey    contextKey = "userID"
+)
+
+// WithRequestID menyimpan request ID ke dalam context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID mengambil request ID dari context, kosong jika tidak ada
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithUserID menyimpan identitas user yang terautentikasi ke dalam context
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID mengambil identitas user dari context, kosong jika request tidak terautentikasi
+func UserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
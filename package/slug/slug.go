@@ -0,0 +1,32 @@
+// Package slug membuat slug URL dari nama, sehingga variasi penulisan seperti
+// "Nike", "nike", dan "NIKE " menghasilkan slug yang sama.
+package slug
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Make mengubah nama menjadi slug huruf kecil dengan tanda hubung, misalnya
+// "Running Shoes & Co" menjadi "running-shoes-co"
+func Make(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// Valid memeriksa apakah s sudah berbentuk slug
+func Valid(s string) bool {
+	return s != "" && Make(s) == s
+}
@@ -0,0 +1,38 @@
+package token
+
+import (
+	"be-stepup/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+)
+
+// ErrInvalidCartToken dikembalikan jika token keranjang tamu rusak atau tanda tangannya tidak cocok
+var ErrInvalidCartToken = errors.New("invalid cart token")
+
+// GenerateCartToken membuat ID keranjang tamu baru beserta token bertanda tangan "<id>.<signature>"
+func GenerateCartToken() (id, token string) {
+	id = uuid.New().String()
+	return id, id + "." + signCartID(id)
+}
+
+// ParseCartToken memverifikasi token keranjang tamu dan mengembalikan ID-nya
+func ParseCartToken(token string) (string, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", ErrInvalidCartToken
+	}
+	if !hmac.Equal([]byte(signature), []byte(signCartID(id))) {
+		return "", ErrInvalidCartToken
+	}
+	return id, nil
+}
+
+func signCartID(id string) string {
+	mac := hmac.New(sha256.New, []byte(config.AuthSecret))
+	mac.Write([]byte("cart:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
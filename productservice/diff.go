@@ -0,0 +1,48 @@
+package productservice
+
+import (
+	"be-stepup/models"
+	"reflect"
+	"strings"
+)
+
+// ignoredFields tidak dibandingkan di riwayat: _id dan version berubah otomatis, rating dihitung
+// dari ulasan, dan stok dicatat terpisah karena juga berubah oleh transaksi di antara baca dan tulis
+var ignoredFields = map[string]bool{
+	"_id":            true,
+	"version":        true,
+	"rating_average": true,
+	"rating_count":   true,
+	"stock":          true,
+}
+
+// Diff membandingkan dua versi produk per field, memakai nama field bson
+func Diff(before, after models.Product) []models.FieldChange {
+	beforeValue, afterValue := reflect.ValueOf(before), reflect.ValueOf(after)
+	productType := beforeValue.Type()
+
+	var changes []models.FieldChange
+	for i := 0; i < productType.NumField(); i++ {
+		field := strings.Split(productType.Field(i).Tag.Get("bson"), ",")[0]
+		if field == "" || field == "-" || ignoredFields[field] {
+			continue
+		}
+		b, a := auditValue(beforeValue.Field(i).Interface()), auditValue(afterValue.Field(i).Interface())
+		if !reflect.DeepEqual(b, a) {
+			changes = append(changes, models.FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+// auditValue melepas pointer agar nilai tersimpan apa adanya; pointer nil menjadi nil
+func auditValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		return value
+	}
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
@@ -0,0 +1,288 @@
+// Package productservice adalah jalur untuk mengubah data produk dari sisi admin. Setiap
+// perubahan dicatat di riwayat produk (product_audits) beserta pelaku dan nilai field sebelum
+// dan sesudahnya, sehingga handler tidak perlu menulis riwayat sendiri. Perubahan stok, termasuk
+// penjualan dari checkout dan pengembalian karena pembatalan, dicatat di ledger inventaris dan
+// juga di riwayat produk dengan jenis pergerakan dan referensinya.
+package productservice
+
+import (
+	"be-stepup/models"
+	"be-stepup/repository"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+// Create menyimpan produk baru dan mencatat stok awalnya sebagai barang masuk di ledger
+func Create(ctx context.Context, product models.Product, actor string) error {
+	if err := repository.InsertProduct(ctx, product); err != nil {
+		return err
+	}
+	record(ctx, product, models.ProductAuditCreate, actor, created(product))
+
+	if product.Stock > 0 {
+		err := repository.InsertInventoryMovement(ctx, &models.InventoryMovement{
+			ProductID:      product.ProductID,
+			ProductCode:    product.Code,
+			Type:           models.MovementRestock,
+			Delta:          product.Stock,
+			ResultingStock: product.Stock,
+			Actor:          actor,
+			Note:           "initial stock",
+		})
+		if err != nil {
+			log.Printf("Error recording initial stock for productID %s: %v\n", product.ProductID, err)
+		}
+	}
+	return nil
+}
+
+// Update mengubah field produk dengan pemeriksaan versi terhadap before.
+// mongo.ErrNoDocuments berarti produk sudah diubah request lain atau dihapus.
+func Update(ctx context.Context, before models.Product, set bson.M, actor string) (models.Product, error) {
+	after, err := repository.UpdateProductFields(ctx, before.ProductID, before.Version, set)
+	if err != nil {
+		return after, err
+	}
+	if changes := Diff(before, after); len(changes) > 0 {
+		record(ctx, after, models.ProductAuditUpdate, actor, changes)
+	}
+	return after, nil
+}
+
+// SetStock mengganti stok produk lewat ledger inventaris; riwayat hanya dicatat jika stok berubah
+func SetStock(ctx context.Context, productID string, stock int, movement models.InventoryMovement) (models.InventoryMovement, bool, error) {
+	recorded, changed, err := repository.SetProductStock(ctx, productID, stock, movement)
+	if err != nil || !changed {
+		return recorded, changed, err
+	}
+	recordStock(ctx, recorded)
+	return recorded, true, nil
+}
+
+// AdjustStock menambah atau mengurangi stok produk lewat ledger inventaris, baik manual maupun
+// karena penjualan.
+// mongo.ErrNoDocuments dikembalikan jika produk tidak ada atau stok tidak cukup.
+func AdjustStock(ctx context.Context, movement models.InventoryMovement) (models.InventoryMovement, error) {
+	recorded, err := repository.AdjustProductStock(ctx, movement)
+	if err != nil {
+		return recorded, err
+	}
+	recordStock(ctx, recorded)
+	return recorded, nil
+}
+
+// RestoreStock mengembalikan stok item checkout yang gagal dibuat atau dibatalkan dan mencatatnya
+// sebagai pembatalan. Produk yang stoknya sudah dikembalikan untuk checkoutID dilewati sehingga
+// aman dipanggil ulang. Semua item tetap dicoba walaupun salah satu gagal; error pertama dikembalikan.
+func RestoreStock(ctx context.Context, items []models.CartItem, actor, checkoutID, note string) error {
+	// Satu pembatalan per produk per checkout, sehingga item produk yang sama digabung
+	var productIDs []string
+	quantities := make(map[string]int, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	var firstErr error
+	for _, productID := range productIDs {
+		recorded, restored, err := repository.RestoreCheckoutStock(ctx, models.InventoryMovement{
+			ProductID:   productID,
+			Type:        models.MovementCancellation,
+			Delta:       quantities[productID],
+			Actor:       actor,
+			ReferenceID: checkoutID,
+			Note:        note,
+		})
+		if err == mongo.ErrNoDocuments {
+			// Produk yang sudah tidak ada tidak punya stok untuk dikembalikan
+			log.Printf("Product %s of checkout %s no longer exists, stock not restored\n", productID, checkoutID)
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if restored {
+			recordStock(ctx, recorded)
+		}
+	}
+	return firstErr
+}
+
+// ReleaseCheckout mengembalikan stok dan kuota promosi checkout yang dibatalkan lalu mengisi
+// stock_released_at. Semua langkah tetap dijalankan walaupun salah satunya gagal; error pertama
+// dikembalikan dan stock_released_at tetap kosong agar job checkout-expiry mengulanginya. Setiap
+// langkah melewati bagian yang sudah dikembalikan sehingga pengulangan tidak menggandakan stok.
+func ReleaseCheckout(ctx context.Context, checkout models.Checkout, actor, note string) error {
+	firstErr := RestoreStock(ctx, checkout.Items, actor, checkout.CheckoutID, note)
+	for _, discount := range checkout.Discounts {
+		err := repository.ReleaseCheckoutPromotionUsage(ctx, discount.PromotionID, checkout.CheckoutID)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return repository.MarkCheckoutStockReleased(ctx, checkout.CheckoutID, time.Now())
+}
+
+// SetStatus mengganti status dan jadwal tayang produk dengan pemeriksaan versi terhadap before.
+// mongo.ErrNoDocuments berarti produk sudah diubah request lain atau dihapus.
+func SetStatus(ctx context.Context, before models.Product, status string, publishAt, unpublishAt *time.Time, actor string) (models.Product, error) {
+	after, err := repository.SetProductStatus(ctx, before.ProductID, before.Version, status, publishAt, unpublishAt)
+	if err != nil {
+		return before, err
+	}
+	if changes := Diff(before, after); len(changes) > 0 {
+		record(ctx, after, models.ProductAuditStatus, actor, changes)
+	}
+	return after, nil
+}
+
+// ApplySchedule menjalankan jadwal tayang produk yang sudah jatuh tempo: draft menjadi aktif
+// saat publish_at tiba dan produk aktif diarsipkan saat unpublish_at lewat. changed bernilai
+// false jika tidak ada jadwal yang jatuh tempo atau produk baru saja diubah admin.
+func ApplySchedule(ctx context.Context, product models.Product, now time.Time, actor string) (after models.Product, changed bool, err error) {
+	status := product.Status
+	if status == models.ProductDraft && product.PublishAt != nil && !product.PublishAt.After(now) {
+		status = models.ProductActive
+	}
+	if status == models.ProductActive && product.UnpublishAt != nil && !product.UnpublishAt.After(now) {
+		status = models.ProductArchived
+	}
+	if status == product.Status {
+		return product, false, nil
+	}
+
+	after, err = repository.UpdateProductFields(ctx, product.ProductID, product.Version, bson.M{"status": status})
+	if err == mongo.ErrNoDocuments {
+		return product, false, nil
+	}
+	if err != nil {
+		return product, false, err
+	}
+	record(ctx, after, models.ProductAuditStatus, actor, Diff(product, after))
+	return after, true, nil
+}
+
+// Delete menandai produk sebagai terhapus; mongo.ErrNoDocuments jika produk sudah terhapus
+func Delete(ctx context.Context, product models.Product, actor string) error {
+	now := time.Now()
+	if err := repository.SoftDeleteProduct(ctx, product.ProductID, now); err != nil {
+		return err
+	}
+	record(ctx, product, models.ProductAuditDelete, actor, []models.FieldChange{
+		{Field: "deleted_at", Before: nil, After: now},
+	})
+	return nil
+}
+
+// Restore memulihkan produk yang terhapus; mongo.ErrNoDocuments jika produk tidak terhapus
+func Restore(ctx context.Context, product models.Product, actor string) (models.Product, error) {
+	after, err := repository.RestoreProduct(ctx, product.ProductID)
+	if err != nil {
+		return product, err
+	}
+	record(ctx, after, models.ProductAuditRestore, actor, []models.FieldChange{
+		{Field: "deleted_at", Before: auditValue(product.DeletedAt), After: nil},
+	})
+	return after, nil
+}
+
+// UpsertDetails menyimpan data katalog produk berdasarkan kode tanpa mengubah stok
+func UpsertDetails(ctx context.Context, product models.Product, actor string) (bool, error) {
+	return upsert(ctx, product.Code, actor, func() (bool, error) {
+		return repository.UpsertProductDetailsByCode(ctx, product)
+	})
+}
+
+// UpsertByCode membuat produk baru atau memperbarui produk dengan kode yang sama, termasuk stoknya
+func UpsertByCode(ctx context.Context, product models.Product, actor string) (bool, error) {
+	return upsert(ctx, product.Code, actor, func() (bool, error) {
+		return repository.UpsertProductByCode(ctx, product, actor)
+	})
+}
+
+// upsert membandingkan produk sebelum dan sesudah save untuk dicatat di riwayat
+func upsert(ctx context.Context, code, actor string, save func() (bool, error)) (bool, error) {
+	before, err := repository.FindProductByCode(ctx, code)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	isNew, err := save()
+	if err != nil {
+		return isNew, err
+	}
+	after, err := repository.FindProductByCode(ctx, code)
+	if err != nil {
+		return isNew, err
+	}
+
+	if isNew {
+		record(ctx, after, models.ProductAuditCreate, actor, created(after))
+		return true, nil
+	}
+	if changes := Diff(before, after); len(changes) > 0 {
+		record(ctx, after, models.ProductAuditUpdate, actor, changes)
+	}
+	if before.Stock != after.Stock {
+		record(ctx, after, models.ProductAuditStock, actor, []models.FieldChange{
+			{Field: "stock", Before: before.Stock, After: after.Stock},
+		})
+	}
+	return false, nil
+}
+
+// recordStock mencatat perubahan stok dari pergerakan ledger yang sudah tersimpan
+func recordStock(ctx context.Context, movement models.InventoryMovement) {
+	audit := models.ProductAudit{
+		ProductID:    movement.ProductID,
+		ProductCode:  movement.ProductCode,
+		Action:       models.ProductAuditStock,
+		Actor:        movement.Actor,
+		MovementType: movement.Type,
+		ReferenceID:  movement.ReferenceID,
+		Changes: []models.FieldChange{
+			{Field: "stock", Before: movement.ResultingStock - movement.Delta, After: movement.ResultingStock},
+		},
+	}
+	if err := repository.InsertProductAudit(ctx, &audit); err != nil {
+		log.Printf("Error recording audit for productID %s: %v\n", movement.ProductID, err)
+	}
+}
+
+// record menyimpan riwayat produk. Kegagalan hanya dicatat agar tidak menggagalkan perubahan
+// yang sudah tersimpan.
+func record(ctx context.Context, product models.Product, action, actor string, changes []models.FieldChange) {
+	audit := models.ProductAudit{
+		ProductID:   product.ProductID,
+		ProductCode: product.Code,
+		Action:      action,
+		Actor:       actor,
+		Changes:     changes,
+	}
+	if err := repository.InsertProductAudit(ctx, &audit); err != nil {
+		log.Printf("Error recording audit for productID %s: %v\n", product.ProductID, err)
+	}
+}
+
+// created mengembalikan semua field produk baru yang terisi, dengan Before nil
+func created(product models.Product) []models.FieldChange {
+	changes := Diff(models.Product{}, product)
+	for i := range changes {
+		changes[i].Before = nil
+	}
+	if product.Stock != 0 {
+		changes = append(changes, models.FieldChange{Field: "stock", After: product.Stock})
+	}
+	return changes
+}
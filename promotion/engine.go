@@ -0,0 +1,252 @@
+// Package promotion menghitung diskon dari voucher dan promosi otomatis
+// terhadap isi keranjang. Paket ini tidak mengakses database; pemeriksaan
+// batas pemakaian dilakukan oleh pemanggil sebelum Evaluate dipanggil.
+package promotion
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInactive         = errors.New("voucher tidak aktif atau sudah kedaluwarsa")
+	ErrMinSpendNotMet   = errors.New("total belanja belum mencapai minimum voucher")
+	ErrNoEligibleItems  = errors.New("voucher tidak berlaku untuk produk di keranjang")
+	ErrCurrencyMismatch = errors.New("mata uang keranjang tidak sama")
+)
+
+// Line adalah satu item keranjang yang dinilai oleh engine promosi
+type Line struct {
+	ProductID  string
+	CategoryID string
+	// CategoryPath berisi CategoryID beserta semua induknya, diisi pemanggil dari pohon kategori
+	// agar promosi untuk kategori induk juga berlaku bagi produk di subkategorinya
+	CategoryPath []string
+	Quantity     int
+	UnitPrice    money.Money
+}
+
+// Total mengembalikan harga satuan dikali kuantitas
+func (l Line) Total() money.Money {
+	return l.UnitPrice.Mul(int64(l.Quantity))
+}
+
+// Result adalah rincian harga setelah promosi
+type Result struct {
+	Subtotal     money.Money              `json:"subtotal"`
+	Discount     money.Money              `json:"discount"`
+	Total        money.Money              `json:"total"`
+	Applied      []models.AppliedDiscount `json:"discounts"`
+	FreeShipping bool                     `json:"free_shipping"`
+}
+
+// Evaluate menerapkan promosi otomatis lalu voucher (jika ada) pada keranjang.
+// Setiap item hanya mendapat satu promosi otomatis, yaitu yang memberi diskon terbesar;
+// voucher dihitung dari sisa harga setelah promosi otomatis.
+func Evaluate(lines []Line, automatic []models.Promotion, voucher *models.Promotion, now time.Time) (Result, error) {
+	var result Result
+	remaining := make([]money.Money, len(lines))
+	for i, line := range lines {
+		remaining[i] = line.Total()
+		subtotal, err := result.Subtotal.Add(remaining[i])
+		if err != nil {
+			return Result{}, ErrCurrencyMismatch
+		}
+		result.Subtotal = subtotal
+	}
+
+	// Hitung setiap promosi otomatis yang memenuhi syarat, lalu terapkan dari yang terbesar
+	type candidate struct {
+		promo    models.Promotion
+		eligible []int
+		discount money.Money
+	}
+	var candidates []candidate
+	for _, promo := range automatic {
+		if !promo.ActiveAt(now) || !minSpendMet(promo, result.Subtotal) {
+			continue
+		}
+		eligible := eligibleLines(promo, lines)
+		if len(eligible) == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{promo, eligible, discountFor(promo, sumLines(remaining, eligible))})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].discount.Amount > candidates[j].discount.Amount
+	})
+
+	used := make([]bool, len(lines))
+	for _, cand := range candidates {
+		// Gratis ongkir tidak memotong harga item sehingga tidak bersaing dengan diskon lain
+		if cand.promo.Type == models.PromotionFreeShipping {
+			result.apply(cand.promo, cand.eligible, remaining)
+			continue
+		}
+
+		overlap := false
+		for _, i := range cand.eligible {
+			if used[i] {
+				overlap = true
+				break
+			}
+		}
+		if overlap {
+			continue
+		}
+		for _, i := range cand.eligible {
+			used[i] = true
+		}
+		result.apply(cand.promo, cand.eligible, remaining)
+	}
+
+	if voucher != nil {
+		if !voucher.ActiveAt(now) {
+			return Result{}, ErrInactive
+		}
+		if !minSpendMet(*voucher, result.Subtotal) {
+			return Result{}, ErrMinSpendNotMet
+		}
+		eligible := eligibleLines(*voucher, lines)
+		if len(eligible) == 0 {
+			return Result{}, ErrNoEligibleItems
+		}
+		result.apply(*voucher, eligible, remaining)
+	}
+
+	total, err := result.Subtotal.Sub(result.Discount)
+	if err != nil {
+		return Result{}, ErrCurrencyMismatch
+	}
+	result.Total = total
+	return result, nil
+}
+
+// apply mencatat diskon promosi dan mengurangi sisa harga item yang tercakup
+func (r *Result) apply(promo models.Promotion, eligible []int, remaining []money.Money) {
+	discount := discountFor(promo, sumLines(remaining, eligible))
+
+	// Bagikan diskon ke item secara berurutan agar sisa harga tidak pernah negatif
+	left := discount.Amount
+	for _, i := range eligible {
+		take := left
+		if take > remaining[i].Amount {
+			take = remaining[i].Amount
+		}
+		remaining[i].Amount -= take
+		left -= take
+	}
+
+	r.Discount.Amount += discount.Amount
+	if r.Discount.Currency == "" {
+		r.Discount.Currency = r.Subtotal.Currency
+	}
+	r.FreeShipping = r.FreeShipping || promo.Type == models.PromotionFreeShipping
+	r.Applied = append(r.Applied, models.AppliedDiscount{
+		PromotionID:  promo.PromotionID,
+		Name:         promo.Name,
+		Code:         promo.Code,
+		Type:         promo.Type,
+		Discount:     discount,
+		FreeShipping: promo.Type == models.PromotionFreeShipping,
+	})
+}
+
+// discountFor menghitung diskon promosi atas nominal yang memenuhi syarat
+func discountFor(promo models.Promotion, base money.Money) money.Money {
+	var amount int64
+	switch promo.Type {
+	case models.PromotionPercentage:
+		amount = base.Amount * int64(promo.Percentage) / 100
+		if promo.MaxDiscount.IsPositive() && amount > promo.MaxDiscount.Amount {
+			amount = promo.MaxDiscount.Amount
+		}
+	case models.PromotionFixedAmount:
+		amount = promo.Amount.Amount
+	}
+	if amount > base.Amount {
+		amount = base.Amount
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return money.New(amount, base.Currency)
+}
+
+func minSpendMet(promo models.Promotion, subtotal money.Money) bool {
+	return subtotal.Amount >= promo.MinSpend.Amount
+}
+
+// eligibleLines mengembalikan indeks item yang tercakup oleh promosi
+func eligibleLines(promo models.Promotion, lines []Line) []int {
+	var eligible []int
+	for i, line := range lines {
+		switch promo.Scope {
+		case models.PromotionScopeProduct:
+			if !contains(promo.ProductIDs, line.ProductID) {
+				continue
+			}
+		case models.PromotionScopeCategory:
+			if !line.inCategories(promo.CategoryIDs) {
+				continue
+			}
+		}
+		eligible = append(eligible, i)
+	}
+	return eligible
+}
+
+// inCategories bernilai true jika kategori item atau salah satu induknya ada di categoryIDs
+func (l Line) inCategories(categoryIDs []string) bool {
+	path := l.CategoryPath
+	if len(path) == 0 && l.CategoryID != "" {
+		path = []string{l.CategoryID}
+	}
+	for _, id := range path {
+		if contains(categoryIDs, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryPaths memetakan setiap category_id ke dirinya beserta semua induknya
+// sampai kategori teratas, untuk mengisi Line.CategoryPath
+func CategoryPaths(categories []models.Category) map[string][]string {
+	parents := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryID] = category.ParentID
+	}
+
+	paths := make(map[string][]string, len(categories))
+	for _, category := range categories {
+		path := []string{category.CategoryID}
+		// Batas panjang mencegah loop tak berujung jika data parent_id membentuk siklus
+		for parent := category.ParentID; parent != "" && len(path) <= len(categories); parent = parents[parent] {
+			path = append(path, parent)
+		}
+		paths[category.CategoryID] = path
+	}
+	return paths
+}
+
+func sumLines(remaining []money.Money, indexes []int) money.Money {
+	var sum money.Money
+	for _, i := range indexes {
+		sum.Amount += remaining[i].Amount
+		sum.Currency = remaining[i].Currency
+	}
+	return sum
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
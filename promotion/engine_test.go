@@ -0,0 +1,232 @@
+package promotion
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCategoryPaths(t *testing.T) {
+	paths := CategoryPaths([]models.Category{
+		{CategoryID: "CAT-shoes"},
+		{CategoryID: "CAT-running", ParentID: "CAT-shoes"},
+		{CategoryID: "CAT-trail", ParentID: "CAT-running"},
+		{CategoryID: "CAT-loop-a", ParentID: "CAT-loop-b"},
+		{CategoryID: "CAT-loop-b", ParentID: "CAT-loop-a"},
+	})
+
+	want := []string{"CAT-trail", "CAT-running", "CAT-shoes"}
+	if !reflect.DeepEqual(paths["CAT-trail"], want) {
+		t.Fatalf("path = %q, want %q", paths["CAT-trail"], want)
+	}
+	if got := paths["CAT-shoes"]; !reflect.DeepEqual(got, []string{"CAT-shoes"}) {
+		t.Fatalf("root path = %q", got)
+	}
+	// parent_id yang membentuk siklus tidak boleh membuat loop tak berujung
+	if got := paths["CAT-loop-a"]; len(got) == 0 || len(got) > 6 {
+		t.Fatalf("cyclic path = %q", got)
+	}
+}
+
+func TestEvaluateCategoryScope(t *testing.T) {
+	paths := CategoryPaths([]models.Category{
+		{CategoryID: "CAT-shoes"},
+		{CategoryID: "CAT-running", ParentID: "CAT-shoes"},
+		{CategoryID: "CAT-sandals"},
+	})
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		categoryIDs []string
+		line        Line
+		want        money.Money
+	}{
+		{"same category", []string{"CAT-running"}, Line{CategoryID: "CAT-running", CategoryPath: paths["CAT-running"]}, money.IDR(10000)},
+		{"parent category", []string{"CAT-shoes"}, Line{CategoryID: "CAT-running", CategoryPath: paths["CAT-running"]}, money.IDR(10000)},
+		{"child does not cover parent", []string{"CAT-running"}, Line{CategoryID: "CAT-shoes", CategoryPath: paths["CAT-shoes"]}, money.Money{}},
+		{"other category", []string{"CAT-sandals"}, Line{CategoryID: "CAT-running", CategoryPath: paths["CAT-running"]}, money.Money{}},
+		{"without path", []string{"CAT-running"}, Line{CategoryID: "CAT-running"}, money.IDR(10000)},
+		{"category name is not an id", []string{"Running"}, Line{CategoryID: "CAT-running", CategoryPath: paths["CAT-running"]}, money.Money{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promo := models.Promotion{
+				PromotionID: "PROMO-1",
+				Type:        models.PromotionPercentage,
+				Scope:       models.PromotionScopeCategory,
+				Percentage:  10,
+				CategoryIDs: tt.categoryIDs,
+				Active:      true,
+			}
+			line := tt.line
+			line.ProductID = "PROD-1"
+			line.Quantity = 1
+			line.UnitPrice = money.IDR(100000)
+
+			result, err := Evaluate([]Line{line}, []models.Promotion{promo}, nil, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Discount.Amount != tt.want.Amount {
+				t.Fatalf("discount = %v, want %v", result.Discount, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	promo := func(id, promoType, scope string) models.Promotion {
+		return models.Promotion{PromotionID: id, Type: promoType, Scope: scope, Active: true}
+	}
+	percentage := func(id, scope string, percent int, productIDs ...string) models.Promotion {
+		p := promo(id, models.PromotionPercentage, scope)
+		p.Percentage = percent
+		p.ProductIDs = productIDs
+		return p
+	}
+	withMax := func(p models.Promotion, max money.Money) models.Promotion {
+		p.MaxDiscount = max
+		return p
+	}
+	withMinSpend := func(p models.Promotion, min money.Money) models.Promotion {
+		p.MinSpend = min
+		return p
+	}
+	fixed := promo("FIXED", models.PromotionFixedAmount, models.PromotionScopeProduct)
+	fixed.Amount = money.IDR(80000)
+	fixed.ProductIDs = []string{"PROD-2"}
+	disabled := percentage("OFF", models.PromotionScopeOrder, 10)
+	disabled.Active = false
+	inactive := percentage("VOUCHER", models.PromotionScopeOrder, 10)
+	inactive.Active = false
+	expired := percentage("VOUCHER", models.PromotionScopeOrder, 10)
+	expired.EndsAt = now.Add(-time.Hour)
+
+	// Subtotal Rp250.000: PROD-1 2 x Rp100.000 dan PROD-2 1 x Rp50.000
+	lines := []Line{
+		{ProductID: "PROD-1", Quantity: 2, UnitPrice: money.IDR(100000)},
+		{ProductID: "PROD-2", Quantity: 1, UnitPrice: money.IDR(50000)},
+	}
+
+	tests := []struct {
+		name         string
+		automatic    []models.Promotion
+		voucher      *models.Promotion
+		wantDiscount money.Money
+		wantApplied  []string
+		wantShipping bool
+		wantErr      error
+	}{
+		{
+			name: "best automatic promotion per line",
+			automatic: []models.Promotion{
+				percentage("P10", models.PromotionScopeProduct, 10, "PROD-1"),
+				percentage("P20", models.PromotionScopeProduct, 20, "PROD-1"),
+			},
+			wantDiscount: money.IDR(40000),
+			wantApplied:  []string{"P20"},
+		},
+		{
+			name: "order promotion overlapping a larger line promotion is skipped",
+			automatic: []models.Promotion{
+				percentage("ORDER10", models.PromotionScopeOrder, 10),
+				percentage("P20", models.PromotionScopeProduct, 20, "PROD-1"),
+			},
+			wantDiscount: money.IDR(40000),
+			wantApplied:  []string{"P20"},
+		},
+		{
+			name:         "voucher applies to the price after automatic discounts",
+			automatic:    []models.Promotion{percentage("P20", models.PromotionScopeProduct, 20, "PROD-1")},
+			voucher:      ptr(percentage("VOUCHER", models.PromotionScopeOrder, 10)),
+			wantDiscount: money.IDR(61000),
+			wantApplied:  []string{"P20", "VOUCHER"},
+		},
+		{
+			name:         "percentage capped by max discount",
+			automatic:    []models.Promotion{withMax(percentage("HALF", models.PromotionScopeOrder, 50), money.IDR(30000))},
+			wantDiscount: money.IDR(30000),
+			wantApplied:  []string{"HALF"},
+		},
+		{
+			name:         "fixed amount capped to the line total",
+			automatic:    []models.Promotion{fixed},
+			wantDiscount: money.IDR(50000),
+			wantApplied:  []string{"FIXED"},
+		},
+		{
+			name: "free shipping does not compete with price discounts",
+			automatic: []models.Promotion{
+				promo("SHIP", models.PromotionFreeShipping, models.PromotionScopeOrder),
+				percentage("P20", models.PromotionScopeProduct, 20, "PROD-1"),
+			},
+			wantDiscount: money.IDR(40000),
+			wantApplied:  []string{"P20", "SHIP"},
+			wantShipping: true,
+		},
+		{
+			name: "inactive and unmet automatic promotions are ignored",
+			automatic: []models.Promotion{
+				disabled,
+				withMinSpend(percentage("BIG", models.PromotionScopeOrder, 10), money.IDR(500000)),
+			},
+			wantDiscount: money.Money{},
+		},
+		{
+			name:    "voucher below minimum spend",
+			voucher: ptr(withMinSpend(percentage("VOUCHER", models.PromotionScopeOrder, 10), money.IDR(300000))),
+			wantErr: ErrMinSpendNotMet,
+		},
+		{
+			name:    "inactive voucher",
+			voucher: &inactive,
+			wantErr: ErrInactive,
+		},
+		{
+			name:    "expired voucher",
+			voucher: &expired,
+			wantErr: ErrInactive,
+		},
+		{
+			name:    "voucher without eligible items",
+			voucher: ptr(percentage("VOUCHER", models.PromotionScopeProduct, 10, "PROD-9")),
+			wantErr: ErrNoEligibleItems,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Evaluate(lines, tt.automatic, tt.voucher, now)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if result.Discount.Amount != tt.wantDiscount.Amount {
+				t.Errorf("discount = %v, want %v", result.Discount, tt.wantDiscount)
+			}
+			if want := money.IDR(250000).Amount - tt.wantDiscount.Amount; result.Total.Amount != want {
+				t.Errorf("total = %v, want %d", result.Total, want)
+			}
+			var applied []string
+			for _, discount := range result.Applied {
+				applied = append(applied, discount.PromotionID)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("applied = %q, want %q", applied, tt.wantApplied)
+			}
+			if result.FreeShipping != tt.wantShipping {
+				t.Errorf("free shipping = %v, want %v", result.FreeShipping, tt.wantShipping)
+			}
+		})
+	}
+}
+
+func ptr(p models.Promotion) *models.Promotion {
+	return &p
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func auditEventsCollection() *mongo.Collection {
+	return config.GetCollection("audit_events")
+}
+
+// InsertAuditEvent menambahkan event audit. Tidak ada fungsi untuk mengubah atau menghapus
+// event; penghapusan hanya dilakukan oleh TTL index pada expires_at.
+func InsertAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	event.EventID = uuid.New().String()
+	_, err := auditEventsCollection().InsertOne(ctx, event)
+	return err
+}
+
+// ListAuditEvents mengambil event audit dengan filter dan paginasi, terbaru lebih dulu
+func ListAuditEvents(ctx context.Context, filter bson.M, page, limit int64) ([]models.AuditEvent, int64, error) {
+	total, err := auditEventsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := auditEventsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func brandsCollection() *mongo.Collection {
+	return config.GetCollection("brands")
+}
+
+// FindBrandByID mengambil merek berdasarkan brand_id
+func FindBrandByID(ctx context.Context, brandID string) (models.Brand, error) {
+	var brand models.Brand
+	err := brandsCollection().FindOne(ctx, bson.M{"brand_id": brandID}).Decode(&brand)
+	return brand, err
+}
+
+// FindBrandBySlug mengambil merek berdasarkan slug
+func FindBrandBySlug(ctx context.Context, slug string) (models.Brand, error) {
+	var brand models.Brand
+	err := brandsCollection().FindOne(ctx, bson.M{"slug": slug}).Decode(&brand)
+	return brand, err
+}
+
+// ListBrands mengambil semua merek, diurutkan berdasarkan nama
+func ListBrands(ctx context.Context) ([]models.Brand, error) {
+	cursor, err := brandsCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	brands := []models.Brand{}
+	if err := cursor.All(ctx, &brands); err != nil {
+		return nil, err
+	}
+	return brands, nil
+}
+
+// InsertBrand menyimpan merek baru; slug duplikat menghasilkan duplicate key error
+func InsertBrand(ctx context.Context, brand models.Brand) error {
+	_, err := brandsCollection().InsertOne(ctx, brand)
+	return err
+}
+
+// UpdateBrand memperbarui nama dan slug merek
+func UpdateBrand(ctx context.Context, brand models.Brand) error {
+	result, err := brandsCollection().UpdateOne(ctx,
+		bson.M{"brand_id": brand.BrandID},
+		bson.M{"$set": bson.M{
+			"name":        brand.Name,
+			"slug":        brand.Slug,
+			"modified_at": brand.ModifiedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteBrand menghapus merek berdasarkan brand_id
+func DeleteBrand(ctx context.Context, brandID string) error {
+	result, err := brandsCollection().DeleteOne(ctx, bson.M{"brand_id": brandID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CountProductsByBrand menghitung semua produk per brand_id, termasuk draft, arsip, dan produk
+// yang dihapus, untuk menjaga merek yang masih direferensikan agar tidak dihapus
+func CountProductsByBrand(ctx context.Context) (map[string]int64, error) {
+	return countProductsBy(ctx, "brand_id", bson.M{})
+}
+
+// CountAvailableProductsByBrand menghitung produk yang tampil di katalog publik pada waktu now
+// per brand_id
+func CountAvailableProductsByBrand(ctx context.Context, now time.Time) (map[string]int64, error) {
+	return countProductsBy(ctx, "brand_id", AvailableProductFilter(now))
+}
+
+// RenameProductsBrand menyalin nama merek terbaru ke produk yang mereferensikannya
+func RenameProductsBrand(ctx context.Context, brandID, name string) error {
+	_, err := productsCollection().UpdateMany(ctx,
+		bson.M{"brand_id": brandID},
+		bson.M{"$set": bson.M{"brand": name}},
+	)
+	return err
+}
+
+// EnsureBrand mengembalikan merek dengan slug yang sama, dan membuatnya jika belum ada
+func EnsureBrand(ctx context.Context, brand models.Brand) (models.Brand, error) {
+	var ensured models.Brand
+	err := brandsCollection().FindOneAndUpdate(ctx,
+		bson.M{"slug": brand.Slug},
+		bson.M{"$setOnInsert": brand},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&ensured)
+	return ensured, err
+}
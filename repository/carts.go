@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// DeleteCartsModifiedBefore menghapus keranjang yang tidak diubah sejak waktu tertentu
+func DeleteCartsModifiedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := config.GetCollection("cart").DeleteMany(ctx, bson.M{"modified_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteCartByUserID menghapus keranjang milik user
+func DeleteCartByUserID(ctx context.Context, userID string) error {
+	_, err := config.GetCollection("cart").DeleteOne(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// RemoveProductFromCarts menghapus item produk dari semua keranjang dan mengembalikan jumlah keranjang yang berubah
+func RemoveProductFromCarts(ctx context.Context, productID string) (int64, error) {
+	result, err := config.GetCollection("cart").UpdateMany(ctx,
+		bson.M{"items.product_id": productID},
+		bson.M{
+			"$pull": bson.M{"items": bson.M{"product_id": productID}},
+			"$set":  bson.M{"modified_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindAbandonedCarts mengambil keranjang user yang tidak diubah sejak before dan belum
+// mendapat pengingat sejak perubahan terakhirnya. Keranjang tamu dilewati karena tidak
+// memiliki tujuan notifikasi.
+func FindAbandonedCarts(ctx context.Context, before time.Time, limit int64) ([]models.Cart, error) {
+	cursor, err := config.GetCollection("cart").Find(ctx,
+		bson.M{
+			"modified_at": bson.M{"$lt": before},
+			"user_id":     bson.M{"$not": bson.M{"$regex": "^guest:"}},
+			"items.0":     bson.M{"$exists": true},
+			"$or": bson.A{
+				bson.M{"reminder_sent_at": bson.M{"$exists": false}},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$reminder_sent_at", "$modified_at"}}},
+			},
+		},
+		options.Find().SetSort(bson.D{{Key: "modified_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var carts []models.Cart
+	if err := cursor.All(ctx, &carts); err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+// MarkCartReminded menandai keranjang sudah diingatkan. Penandaan hanya berhasil jika keranjang
+// belum diubah sejak modifiedAt dan belum ditandai, sehingga setiap keranjang hanya diingatkan sekali.
+func MarkCartReminded(ctx context.Context, cartID string, modifiedAt, remindedAt time.Time) (bool, error) {
+	result, err := config.GetCollection("cart").UpdateOne(ctx,
+		bson.M{
+			"cart_id":     cartID,
+			"modified_at": modifiedAt,
+			"$or": bson.A{
+				bson.M{"reminder_sent_at": bson.M{"$exists": false}},
+				bson.M{"reminder_sent_at": bson.M{"$lt": modifiedAt}},
+			},
+		},
+		bson.M{"$set": bson.M{"reminder_sent_at": remindedAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func categoriesCollection() *mongo.Collection {
+	return config.GetCollection("categories")
+}
+
+// FindCategoryByID mengambil kategori berdasarkan category_id
+func FindCategoryByID(ctx context.Context, categoryID string) (models.Category, error) {
+	var category models.Category
+	err := categoriesCollection().FindOne(ctx, bson.M{"category_id": categoryID}).Decode(&category)
+	return category, err
+}
+
+// FindCategoryBySlug mengambil kategori berdasarkan slug
+func FindCategoryBySlug(ctx context.Context, slug string) (models.Category, error) {
+	var category models.Category
+	err := categoriesCollection().FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+	return category, err
+}
+
+// ListCategories mengambil semua kategori, diurutkan berdasarkan nama
+func ListCategories(ctx context.Context) ([]models.Category, error) {
+	cursor, err := categoriesCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// InsertCategory menyimpan kategori baru; slug duplikat menghasilkan duplicate key error
+func InsertCategory(ctx context.Context, category models.Category) error {
+	_, err := categoriesCollection().InsertOne(ctx, category)
+	return err
+}
+
+// UpdateCategory memperbarui nama, slug, dan induk kategori
+func UpdateCategory(ctx context.Context, category models.Category) error {
+	result, err := categoriesCollection().UpdateOne(ctx,
+		bson.M{"category_id": category.CategoryID},
+		bson.M{"$set": bson.M{
+			"name":        category.Name,
+			"slug":        category.Slug,
+			"parent_id":   category.ParentID,
+			"modified_at": category.ModifiedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteCategory menghapus kategori berdasarkan category_id
+func DeleteCategory(ctx context.Context, categoryID string) error {
+	result, err := categoriesCollection().DeleteOne(ctx, bson.M{"category_id": categoryID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CountChildCategories menghitung subkategori langsung dari sebuah kategori
+func CountChildCategories(ctx context.Context, categoryID string) (int64, error) {
+	return categoriesCollection().CountDocuments(ctx, bson.M{"parent_id": categoryID})
+}
+
+// CountProductsByCategory menghitung semua produk per category_id, termasuk draft, arsip, dan
+// produk yang dihapus, untuk menjaga kategori yang masih direferensikan agar tidak dihapus
+func CountProductsByCategory(ctx context.Context) (map[string]int64, error) {
+	return countProductsBy(ctx, "category_id", bson.M{})
+}
+
+// CountAvailableProductsByCategory menghitung produk yang tampil di katalog publik pada waktu now
+// per category_id
+func CountAvailableProductsByCategory(ctx context.Context, now time.Time) (map[string]int64, error) {
+	return countProductsBy(ctx, "category_id", AvailableProductFilter(now))
+}
+
+// RenameProductsCategory menyalin nama kategori terbaru ke produk yang mereferensikannya
+func RenameProductsCategory(ctx context.Context, categoryID, name string) error {
+	_, err := productsCollection().UpdateMany(ctx,
+		bson.M{"category_id": categoryID},
+		bson.M{"$set": bson.M{"category": name}},
+	)
+	return err
+}
+
+// countProductsBy menghitung produk yang cocok dengan filter per nilai field referensi; produk
+// tanpa referensi diabaikan
+func countProductsBy(ctx context.Context, field string, filter bson.M) (map[string]int64, error) {
+	match := bson.M{field: bson.M{"$nin": bson.A{"", nil}}}
+	for key, value := range filter {
+		match[key] = value
+	}
+	cursor, err := productsCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID    string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+// EnsureCategory mengembalikan kategori dengan slug yang sama, dan membuatnya jika belum ada
+func EnsureCategory(ctx context.Context, category models.Category) (models.Category, error) {
+	var ensured models.Category
+	err := categoriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"slug": category.Slug},
+		bson.M{"$setOnInsert": category},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&ensured)
+	return ensured, err
+}
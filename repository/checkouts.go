@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func checkoutsCollection() *mongo.Collection {
+	return config.GetCollection("checkout")
+}
+
+// FindCheckoutByID mengambil checkout berdasarkan checkout_id
+func FindCheckoutByID(ctx context.Context, checkoutID string) (models.Checkout, error) {
+	var checkout models.Checkout
+	err := checkoutsCollection().FindOne(ctx, bson.M{"checkout_id": checkoutID}).Decode(&checkout)
+	return checkout, err
+}
+
+// UpdateCheckoutStatus mengganti status checkout hanya jika versinya masih sama dengan version.
+// mongo.ErrNoDocuments berarti checkout sudah diubah request lain.
+func UpdateCheckoutStatus(ctx context.Context, checkoutID string, version int64, status string, now time.Time) (models.Checkout, error) {
+	var checkout models.Checkout
+	err := checkoutsCollection().FindOneAndUpdate(ctx,
+		bson.M{"checkout_id": checkoutID, "version": version},
+		bson.M{
+			"$set": bson.M{"status": status, "modified_at": now},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&checkout)
+	return checkout, err
+}
+
+// CancelCheckout membatalkan checkout Pending hanya jika versinya masih sama dengan version.
+// mongo.ErrNoDocuments berarti checkout sudah diubah request lain atau tidak lagi Pending.
+func CancelCheckout(ctx context.Context, checkoutID string, version int64, reason string, now time.Time) (models.Checkout, error) {
+	var checkout models.Checkout
+	err := checkoutsCollection().FindOneAndUpdate(ctx,
+		bson.M{"checkout_id": checkoutID, "version": version, "status": models.CheckoutStatusPending},
+		bson.M{
+			"$set": bson.M{
+				"status":        models.CheckoutStatusCancelled,
+				"cancel_reason": reason,
+				"cancelled_at":  now,
+				"modified_at":   now,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&checkout)
+	return checkout, err
+}
+
+// DeleteFinishedCheckout menghapus checkout yang sudah Completed, atau Cancelled dan stoknya sudah
+// dikembalikan. Checkout Pending masih memegang stok dan kuota promosi sehingga harus dibatalkan
+// lebih dulu; mongo.ErrNoDocuments dikembalikan jika checkout tidak ada atau belum selesai.
+func DeleteFinishedCheckout(ctx context.Context, checkoutID string) error {
+	result, err := checkoutsCollection().DeleteOne(ctx, bson.M{
+		"checkout_id": checkoutID,
+		"$or": bson.A{
+			bson.M{"status": models.CheckoutStatusCompleted},
+			bson.M{"status": models.CheckoutStatusCancelled, "stock_released_at": bson.M{"$ne": nil}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindAllCheckouts mengambil semua checkout, terbaru lebih dulu
+func FindAllCheckouts(ctx context.Context) ([]models.Checkout, error) {
+	cursor, err := config.GetCollection("checkout").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkouts []models.Checkout
+	if err := cursor.All(ctx, &checkouts); err != nil {
+		return nil, err
+	}
+	return checkouts, nil
+}
+
+// AnonymizedUserName menggantikan nama user pada riwayat transaksi akun yang dihapus
+const AnonymizedUserName = "Pengguna dihapus"
+
+// AnonymizeCheckoutsByUser menghapus data pribadi dari checkout milik user.
+// Item dan nominal tetap disimpan untuk keperluan laporan penjualan.
+func AnonymizeCheckoutsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := config.GetCollection("checkout").UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{
+			"$set": bson.M{
+				"user_name":        AnonymizedUserName,
+				"recipient_name":   "",
+				"phone_number":     "",
+				"address":          "",
+				"shipping_address": models.Address{},
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindExpiredCheckouts mengambil checkout Pending yang batas waktu pembayarannya sudah lewat
+func FindExpiredCheckouts(ctx context.Context, now time.Time, limit int64) ([]models.Checkout, error) {
+	cursor, err := config.GetCollection("checkout").Find(ctx,
+		bson.M{
+			"status":           models.CheckoutStatusPending,
+			"payment_deadline": bson.M{"$lte": now},
+		},
+		options.Find().SetSort(bson.D{{Key: "payment_deadline", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkouts []models.Checkout
+	if err := cursor.All(ctx, &checkouts); err != nil {
+		return nil, err
+	}
+	return checkouts, nil
+}
+
+// FindUnreleasedCheckouts mengambil checkout Cancelled yang dibatalkan sebelum before tetapi stok
+// dan kuota promosinya belum tercatat kembali, misalnya karena pengembalian sebelumnya gagal
+func FindUnreleasedCheckouts(ctx context.Context, before time.Time, limit int64) ([]models.Checkout, error) {
+	cursor, err := checkoutsCollection().Find(ctx,
+		bson.M{
+			"status":            models.CheckoutStatusCancelled,
+			"stock_released_at": nil,
+			"cancelled_at":      bson.M{"$lte": before},
+		},
+		options.Find().SetSort(bson.D{{Key: "cancelled_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkouts []models.Checkout
+	if err := cursor.All(ctx, &checkouts); err != nil {
+		return nil, err
+	}
+	return checkouts, nil
+}
+
+// MarkCheckoutStockReleased mencatat bahwa stok dan kuota promosi checkout sudah dikembalikan
+func MarkCheckoutStockReleased(ctx context.Context, checkoutID string, now time.Time) error {
+	_, err := checkoutsCollection().UpdateOne(ctx,
+		bson.M{"checkout_id": checkoutID},
+		bson.M{"$set": bson.M{"stock_released_at": now}},
+	)
+	return err
+}
+
+// CancelExpiredCheckout membatalkan checkout yang masih Pending dan sudah melewati batas waktu
+// pembayaran. Mengembalikan false jika checkout sudah berubah status, misalnya sudah dibatalkan
+// replika lain atau sudah dibayar.
+func CancelExpiredCheckout(ctx context.Context, checkoutID string, now time.Time) (bool, error) {
+	result, err := config.GetCollection("checkout").UpdateOne(ctx,
+		bson.M{
+			"checkout_id":      checkoutID,
+			"status":           models.CheckoutStatusPending,
+			"payment_deadline": bson.M{"$lte": now},
+		},
+		bson.M{
+			"$set": bson.M{
+				"status":        models.CheckoutStatusCancelled,
+				"cancel_reason": models.CheckoutCancelPaymentExpired,
+				"cancelled_at":  now,
+				"modified_at":   now,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
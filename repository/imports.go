@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func importJobsCollection() *mongo.Collection {
+	return config.GetCollection("import_jobs")
+}
+
+// InsertImportJob menyimpan job impor baru
+func InsertImportJob(ctx context.Context, job models.ImportJob) error {
+	_, err := importJobsCollection().InsertOne(ctx, job)
+	return err
+}
+
+// SaveImportJob menyimpan progres terbaru job impor
+func SaveImportJob(ctx context.Context, job models.ImportJob) error {
+	_, err := importJobsCollection().ReplaceOne(ctx, bson.M{"job_id": job.JobID}, job)
+	return err
+}
+
+// FindImportJob mengambil job impor berdasarkan job_id
+func FindImportJob(ctx context.Context, jobID string) (models.ImportJob, error) {
+	var job models.ImportJob
+	err := importJobsCollection().FindOne(ctx, bson.M{"job_id": jobID}).Decode(&job)
+	return job, err
+}
+
+// UpsertProductDetailsByCode menyimpan data katalog produk berdasarkan kode tanpa mengubah stok.
+// Produk baru dibuat dengan stok 0 sehingga stok awalnya bisa dicatat di ledger oleh pemanggil;
+// field yang tidak ada di file katalog hanya diisi saat produk dibuat.
+func UpsertProductDetailsByCode(ctx context.Context, product models.Product) (created bool, err error) {
+	result, err := productsCollection().UpdateOne(ctx,
+		bson.M{"code": product.Code},
+		bson.M{
+			"$set": bson.M{
+				"name":        product.Name,
+				"brand_id":    product.BrandID,
+				"brand":       product.Brand,
+				"category_id": product.CategoryID,
+				"category":    product.Category,
+				"color":       product.Color,
+				"price":       product.Price,
+				"image_url":   product.ImageURL,
+			},
+			"$setOnInsert": bson.M{
+				"product_id":          product.ProductID,
+				"description":         product.Description,
+				"weight_grams":        product.WeightGrams,
+				"stock":               0,
+				"max_order_quantity":  0,
+				"low_stock_threshold": 0,
+				"rating_average":      0,
+				"rating_count":        0,
+				"status":              models.ProductActive,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// SystemActor menandai perubahan stok oleh proses tanpa user, misalnya job atau CLI
+const SystemActor = "system"
+
+func inventoryCollection() *mongo.Collection {
+	return config.GetCollection("inventory_movements")
+}
+
+// AdjustProductStock mengubah stok produk sebesar movement.Delta dan mencatat pergerakannya dalam
+// satu transaksi, sehingga stok tidak pernah berubah tanpa catatan ledger. Stok tidak boleh menjadi
+// negatif; mongo.ErrNoDocuments dikembalikan jika produk tidak ada atau stok tidak cukup.
+func AdjustProductStock(ctx context.Context, movement models.InventoryMovement) (models.InventoryMovement, error) {
+	filter := bson.M{"product_id": movement.ProductID}
+	if movement.Delta < 0 {
+		filter["stock"] = bson.M{"$gte": -movement.Delta}
+	}
+
+	recorded := movement
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		var product models.Product
+		err := productsCollection().FindOneAndUpdate(sc, filter,
+			bson.M{"$inc": bson.M{"stock": movement.Delta}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&product)
+		if err != nil {
+			return err
+		}
+
+		recorded = movement
+		recorded.ProductCode = product.Code
+		recorded.ResultingStock = product.Stock
+		return InsertInventoryMovement(sc, &recorded)
+	})
+	if err != nil {
+		return movement, err
+	}
+	return recorded, nil
+}
+
+// RestoreCheckoutStock mengembalikan stok produk dari checkout movement.ReferenceID sebagai
+// pembatalan dalam satu transaksi. restored bernilai false jika pembatalan untuk produk dan
+// checkout yang sama sudah tercatat, sehingga pengembalian aman diulang setelah gagal di tengah jalan.
+func RestoreCheckoutStock(ctx context.Context, movement models.InventoryMovement) (recorded models.InventoryMovement, restored bool, err error) {
+	recorded = movement
+	err = withTransaction(ctx, func(sc mongo.SessionContext) error {
+		restored = false
+		count, err := inventoryCollection().CountDocuments(sc, bson.M{
+			"product_id":   movement.ProductID,
+			"reference_id": movement.ReferenceID,
+			"type":         models.MovementCancellation,
+		}, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		// Update stok produk membuat dua pengembalian bersamaan bentrok sehingga salah satunya
+		// diulang dan melihat pembatalan yang sudah tercatat
+		var product models.Product
+		err = productsCollection().FindOneAndUpdate(sc,
+			bson.M{"product_id": movement.ProductID},
+			bson.M{"$inc": bson.M{"stock": movement.Delta}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&product)
+		if err != nil {
+			return err
+		}
+
+		recorded = movement
+		recorded.Type = models.MovementCancellation
+		recorded.ProductCode = product.Code
+		recorded.ResultingStock = product.Stock
+		if err := InsertInventoryMovement(sc, &recorded); err != nil {
+			return err
+		}
+		restored = true
+		return nil
+	})
+	if err != nil {
+		return movement, false, err
+	}
+	return recorded, restored, nil
+}
+
+// SetProductStock mengganti stok produk dengan nilai baru dan mencatat selisihnya sebagai
+// pergerakan dalam satu transaksi. changed bernilai false dan tidak ada pergerakan yang dicatat
+// jika stok tidak berubah.
+func SetProductStock(ctx context.Context, productID string, stock int, movement models.InventoryMovement) (recorded models.InventoryMovement, changed bool, err error) {
+	recorded = movement
+	err = withTransaction(ctx, func(sc mongo.SessionContext) error {
+		var before models.Product
+		err := productsCollection().FindOneAndUpdate(sc,
+			bson.M{"product_id": productID},
+			bson.M{"$set": bson.M{"stock": stock}},
+			options.FindOneAndUpdate().SetReturnDocument(options.Before),
+		).Decode(&before)
+		if err != nil {
+			return err
+		}
+		changed = before.Stock != stock
+		if !changed {
+			return nil
+		}
+
+		recorded = movement
+		recorded.ProductID = productID
+		recorded.ProductCode = before.Code
+		recorded.Delta = stock - before.Stock
+		recorded.ResultingStock = stock
+		return InsertInventoryMovement(sc, &recorded)
+	})
+	if err != nil {
+		return movement, false, err
+	}
+	return recorded, changed, nil
+}
+
+// InsertInventoryMovement menyimpan pergerakan stok yang sudah diterapkan ke produk,
+// misalnya stok awal saat produk dibuat
+func InsertInventoryMovement(ctx context.Context, movement *models.InventoryMovement) error {
+	movement.MovementID = uuid.New().String()
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = time.Now()
+	}
+	_, err := inventoryCollection().InsertOne(ctx, movement)
+	return err
+}
+
+// ListInventoryMovements mengambil pergerakan stok dengan filter dan paginasi, terbaru lebih dulu
+func ListInventoryMovements(ctx context.Context, filter bson.M, page, limit int64) ([]models.InventoryMovement, int64, error) {
+	total, err := inventoryCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := inventoryCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	movements := []models.InventoryMovement{}
+	if err := cursor.All(ctx, &movements); err != nil {
+		return nil, 0, err
+	}
+	return movements, total, nil
+}
+
+// InventoryBalances menjumlahkan delta dan mengambil stok hasil pergerakan terakhir setiap produk.
+// Urutan memakai _id (ObjectID yang dibuat saat insert) karena beberapa pergerakan bisa punya
+// created_at yang sama, dan $last setelah urutan yang tidak stabil bisa memilih pergerakan lama.
+func InventoryBalances(ctx context.Context) (map[string]models.InventoryBalance, error) {
+	cursor, err := inventoryCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                  "$product_id",
+			"total":                bson.M{"$sum": "$delta"},
+			"last_resulting_stock": bson.M{"$last": "$resulting_stock"},
+			"movements":            bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var balances []models.InventoryBalance
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+	byProduct := make(map[string]models.InventoryBalance, len(balances))
+	for _, balance := range balances {
+		byProduct[balance.ProductID] = balance
+	}
+	return byProduct, nil
+}
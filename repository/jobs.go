@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func jobsCollection() *mongo.Collection {
+	return config.GetCollection("job_locks")
+}
+
+// AcquireJobLease mencoba memegang lease job sampai waktu until. Lease berhasil diambil jika
+// belum ada pemegang, lease sebelumnya sudah habis, atau owner sendiri yang memegangnya.
+// acquired bernilai false jika lease masih dipegang replika lain.
+func AcquireJobLease(ctx context.Context, name, owner string, interval time.Duration, now, until time.Time) (acquired bool, err error) {
+	_, err = jobsCollection().UpdateOne(ctx,
+		bson.M{
+			"_id": name,
+			"$or": bson.A{
+				bson.M{"owner": owner},
+				bson.M{"locked_until": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"owner":           owner,
+			"interval":        interval.String(),
+			"locked_until":    until,
+			"last_started_at": now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// Filter tidak cocok karena lease dipegang replika lain, sehingga upsert bentrok dengan _id yang sudah ada
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FinishJobRun mencatat hasil run job oleh pemegang lease
+func FinishJobRun(ctx context.Context, name, owner string, finishedAt time.Time, duration time.Duration, result map[string]int64, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	_, err := jobsCollection().UpdateOne(ctx,
+		bson.M{"_id": name, "owner": owner},
+		bson.M{
+			"$set": bson.M{
+				"last_finished_at": finishedAt,
+				"last_duration_ms": duration.Milliseconds(),
+				"last_error":       lastError,
+				"last_result":      result,
+			},
+			"$inc": bson.M{"runs": 1},
+		},
+	)
+	return err
+}
+
+// ListJobStates mengambil status semua job yang pernah berjalan
+func ListJobStates(ctx context.Context) ([]models.JobState, error) {
+	cursor, err := jobsCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := []models.JobState{}
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func lowStockAlertsCollection() *mongo.Collection {
+	return config.GetCollection("low_stock_alerts")
+}
+
+// InsertLowStockAlert menyimpan peringatan stok menipis
+func InsertLowStockAlert(ctx context.Context, alert *models.LowStockAlert) error {
+	alert.AlertID = uuid.New().String()
+	alert.CreatedAt = time.Now()
+	_, err := lowStockAlertsCollection().InsertOne(ctx, alert)
+	return err
+}
+
+// ListLowStockAlerts mengambil peringatan stok menipis dengan paginasi, terbaru lebih dulu
+func ListLowStockAlerts(ctx context.Context, filter bson.M, page, limit int64) ([]models.LowStockAlert, int64, error) {
+	total, err := lowStockAlertsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := lowStockAlertsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []models.LowStockAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
+
+// FindLowStockProducts mengambil produk yang stoknya sudah mencapai batas stok menipis;
+// produk yang dihapus tidak perlu diisi ulang sehingga diabaikan
+func FindLowStockProducts(ctx context.Context) ([]models.Product, error) {
+	cursor, err := productsCollection().Find(ctx, bson.M{
+		"deleted_at":          nil,
+		"low_stock_threshold": bson.M{"$gt": 0},
+		"$expr":               bson.M{"$lte": bson.A{"$stock", "$low_stock_threshold"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// SalesByProduct menjumlahkan unit terjual per produk sejak waktu tertentu dari ledger inventaris.
+// Penjualan yang dibatalkan tidak dikurangi karena tetap menunjukkan permintaan.
+func SalesByProduct(ctx context.Context, productIDs []string, since time.Time) (map[string]int, error) {
+	cursor, err := inventoryCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"product_id": bson.M{"$in": productIDs},
+			"type":       models.MovementSale,
+			"created_at": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$product_id",
+			"units": bson.M{"$sum": bson.M{"$multiply": bson.A{"$delta", -1}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sales []models.ProductSales
+	if err := cursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+	byProduct := make(map[string]int, len(sales))
+	for _, s := range sales {
+		byProduct[s.ProductID] = s.Units
+	}
+	return byProduct, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// EnqueueNotification menyimpan notifikasi berstatus pending untuk dikirim oleh kanal notifikasi
+func EnqueueNotification(ctx context.Context, notification models.Notification) (models.Notification, error) {
+	notification.NotificationID = uuid.New().String()
+	notification.Status = models.NotificationPending
+	notification.CreatedAt = time.Now()
+
+	_, err := config.GetCollection("notifications").InsertOne(ctx, notification)
+	return notification, err
+}
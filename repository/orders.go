@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AnonymizeOrdersByUser menghapus nama dan alamat dari pesanan milik user
+func AnonymizeOrdersByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := config.GetCollection("orders").UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{
+			"user_name":        AnonymizedUserName,
+			"shipping_address": "",
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
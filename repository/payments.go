@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func paymentsCollection() *mongo.Collection {
+	return config.GetCollection("payment")
+}
+
+// FindPaymentByID mengambil pembayaran berdasarkan payment_id
+func FindPaymentByID(ctx context.Context, paymentID string) (models.Payment, error) {
+	var payment models.Payment
+	err := paymentsCollection().FindOne(ctx, bson.M{"payment_id": paymentID}).Decode(&payment)
+	return payment, err
+}
+
+// UpdatePaymentStatus mengganti status pembayaran hanya jika versinya masih sama dengan version.
+// mongo.ErrNoDocuments berarti pembayaran sudah diubah request lain.
+func UpdatePaymentStatus(ctx context.Context, paymentID string, version int64, status string, now time.Time) (models.Payment, error) {
+	var payment models.Payment
+	err := paymentsCollection().FindOneAndUpdate(ctx,
+		bson.M{"payment_id": paymentID, "version": version},
+		bson.M{
+			"$set": bson.M{"payment_status": status, "modified_at": now},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&payment)
+	return payment, err
+}
+
+// HasActivePaymentForCheckout memeriksa apakah checkout memiliki bukti pembayaran yang masih
+// menunggu verifikasi atau sudah diverifikasi; bukti yang ditolak tidak dihitung
+func HasActivePaymentForCheckout(ctx context.Context, checkoutID string) (bool, error) {
+	count, err := paymentsCollection().CountDocuments(ctx,
+		bson.M{
+			"checkout_id":    checkoutID,
+			"payment_status": bson.M{"$in": bson.A{models.PaymentStatusPending, models.PaymentStatusVerified}},
+		},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
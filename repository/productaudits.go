@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func productAuditsCollection() *mongo.Collection {
+	return config.GetCollection("product_audits")
+}
+
+// InsertProductAudit menambahkan catatan riwayat produk
+func InsertProductAudit(ctx context.Context, audit *models.ProductAudit) error {
+	audit.AuditID = uuid.New().String()
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	_, err := productAuditsCollection().InsertOne(ctx, audit)
+	return err
+}
+
+// ListProductAudits mengambil riwayat produk dengan filter dan paginasi, terbaru lebih dulu
+func ListProductAudits(ctx context.Context, filter bson.M, page, limit int64) ([]models.ProductAudit, int64, error) {
+	total, err := productAuditsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := productAuditsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	audits := []models.ProductAudit{}
+	if err := cursor.All(ctx, &audits); err != nil {
+		return nil, 0, err
+	}
+	return audits, total, nil
+}
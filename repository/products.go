@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func productsCollection() *mongo.Collection {
+	return config.GetCollection("products")
+}
+
+// FindProductByProductID mengambil produk berdasarkan product_id
+func FindProductByProductID(ctx context.Context, productID string) (models.Product, error) {
+	var product models.Product
+	err := productsCollection().FindOne(ctx, bson.M{"product_id": productID}).Decode(&product)
+	return product, err
+}
+
+// FindProductByObjectID mengambil produk berdasarkan _id, termasuk produk yang sudah dihapus
+func FindProductByObjectID(ctx context.Context, id primitive.ObjectID) (models.Product, error) {
+	var product models.Product
+	err := productsCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&product)
+	return product, err
+}
+
+// UpsertProductByCode membuat produk baru atau memperbarui produk dengan kode yang sama.
+// product_id hanya diisi saat dokumen baru dibuat sehingga referensi lama tetap valid.
+// Perubahan stok dicatat di ledger inventaris atas nama actor.
+func UpsertProductByCode(ctx context.Context, product models.Product, actor string) (created bool, err error) {
+	result, err := productsCollection().UpdateOne(ctx,
+		bson.M{"code": product.Code},
+		bson.M{
+			"$set": bson.M{
+				"name":         product.Name,
+				"description":  product.Description,
+				"brand_id":     product.BrandID,
+				"brand":        product.Brand,
+				"category_id":  product.CategoryID,
+				"category":     product.Category,
+				"color":        product.Color,
+				"price":        product.Price,
+				"weight_grams": product.WeightGrams,
+				"image_url":    product.ImageURL,
+			},
+			"$setOnInsert": bson.M{"product_id": product.ProductID, "stock": 0, "status": models.ProductActive},
+			"$inc":         bson.M{"version": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	created = result.UpsertedCount > 0
+
+	existing, err := FindProductByCode(ctx, product.Code)
+	if err != nil {
+		return created, err
+	}
+	movementType := models.MovementAdjustment
+	if created {
+		movementType = models.MovementRestock
+	}
+	_, _, err = SetProductStock(ctx, existing.ProductID, product.Stock, models.InventoryMovement{
+		Type:  movementType,
+		Actor: actor,
+		Note:  "upsert by code",
+	})
+	return created, err
+}
+
+// InsertProduct menyimpan produk baru
+func InsertProduct(ctx context.Context, product models.Product) error {
+	_, err := productsCollection().InsertOne(ctx, product)
+	return err
+}
+
+// FindProductByCode mengambil produk berdasarkan kode unik
+func FindProductByCode(ctx context.Context, code string) (models.Product, error) {
+	var product models.Product
+	err := productsCollection().FindOne(ctx, bson.M{"code": code}).Decode(&product)
+	return product, err
+}
+
+// FindAllProducts mengambil semua produk, diurutkan berdasarkan kode
+func FindAllProducts(ctx context.Context) ([]models.Product, error) {
+	cursor, err := productsCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "code", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// FindProductsByProductIDs mengambil beberapa produk sekaligus, dikelompokkan per product_id
+func FindProductsByProductIDs(ctx context.Context, productIDs []string) (map[string]models.Product, error) {
+	cursor, err := productsCollection().Find(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+	return byID, nil
+}
+
+// AvailableProductFilter memilih produk yang tampil di katalog publik pada waktu now,
+// dengan aturan yang sama seperti models.Product.Available
+func AvailableProductFilter(now time.Time) bson.M {
+	return bson.M{
+		"deleted_at": nil,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"status": models.ProductActive},
+				bson.M{"status": models.ProductDraft, "publish_at": bson.M{"$lte": now}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"unpublish_at": nil},
+				bson.M{"unpublish_at": bson.M{"$gt": now}},
+			}},
+		},
+	}
+}
+
+// ListProducts mengambil produk dengan filter dan paginasi, diurutkan berdasarkan kode
+func ListProducts(ctx context.Context, filter bson.M, page, limit int64) ([]models.Product, int64, error) {
+	total, err := productsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "code", Value: 1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := productsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// SoftDeleteProduct menandai produk sebagai terhapus tanpa menghapus dokumennya, sehingga
+// checkout lama yang mereferensikan product_id tetap bisa dibaca
+func SoftDeleteProduct(ctx context.Context, productID string, now time.Time) error {
+	result, err := productsCollection().UpdateOne(ctx,
+		bson.M{"product_id": productID, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RestoreProduct memulihkan produk yang terhapus dengan status sebelum dihapus dan
+// mengembalikan dokumen setelah dipulihkan
+func RestoreProduct(ctx context.Context, productID string) (models.Product, error) {
+	var product models.Product
+	err := productsCollection().FindOneAndUpdate(ctx,
+		bson.M{"product_id": productID, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&product)
+	return product, err
+}
+
+// SetProductStatus mengganti status dan jadwal tayang produk jika versinya masih sama dengan
+// version; jadwal nil dihapus. mongo.ErrNoDocuments berarti produk sudah diubah request lain
+// atau dihapus.
+func SetProductStatus(ctx context.Context, productID string, version int64, status string, publishAt, unpublishAt *time.Time) (models.Product, error) {
+	set := bson.M{"status": status}
+	unset := bson.M{}
+	if publishAt != nil {
+		set["publish_at"] = *publishAt
+	} else {
+		unset["publish_at"] = ""
+	}
+	if unpublishAt != nil {
+		set["unpublish_at"] = *unpublishAt
+	} else {
+		unset["unpublish_at"] = ""
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	var product models.Product
+	err := productsCollection().FindOneAndUpdate(ctx,
+		bson.M{"product_id": productID, "version": version, "deleted_at": nil},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&product)
+	return product, err
+}
+
+// FindScheduledProducts mengambil produk yang jadwal tayangnya sudah jatuh tempo: draft dengan
+// publish_at yang sudah tiba dan produk aktif dengan unpublish_at yang sudah lewat
+func FindScheduledProducts(ctx context.Context, now time.Time) ([]models.Product, error) {
+	cursor, err := productsCollection().Find(ctx, bson.M{
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"status": models.ProductDraft, "publish_at": bson.M{"$lte": now}},
+			bson.M{"status": models.ProductActive, "unpublish_at": bson.M{"$lte": now}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// UpdateProductFields mengubah field produk hanya jika versinya masih sama dengan version lalu
+// menaikkan versi. mongo.ErrNoDocuments berarti produk sudah diubah request lain atau dihapus.
+func UpdateProductFields(ctx context.Context, productID string, version int64, set bson.M) (models.Product, error) {
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+
+	var product models.Product
+	err := productsCollection().FindOneAndUpdate(ctx,
+		bson.M{"product_id": productID, "version": version, "deleted_at": nil},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&product)
+	return product, err
+}
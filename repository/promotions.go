@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func promotionsCollection() *mongo.Collection {
+	return config.GetCollection("promotions")
+}
+
+// FindPromotionByID mengambil promosi berdasarkan promotion_id
+func FindPromotionByID(ctx context.Context, promotionID string) (models.Promotion, error) {
+	var promo models.Promotion
+	err := promotionsCollection().FindOne(ctx, bson.M{"promotion_id": promotionID}).Decode(&promo)
+	return promo, err
+}
+
+// FindPromotionByCode mengambil voucher berdasarkan kode
+func FindPromotionByCode(ctx context.Context, code string) (models.Promotion, error) {
+	var promo models.Promotion
+	err := promotionsCollection().FindOne(ctx, bson.M{"code": code}).Decode(&promo)
+	return promo, err
+}
+
+// FindAutomaticPromotions mengambil promosi tanpa kode yang aktif pada waktu tertentu
+func FindAutomaticPromotions(ctx context.Context, now time.Time) ([]models.Promotion, error) {
+	filter := bson.M{
+		"code":      bson.M{"$exists": false},
+		"active":    true,
+		"starts_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"ends_at": bson.M{"$gt": now}},
+			bson.M{"ends_at": time.Time{}},
+		},
+	}
+	cursor, err := promotionsCollection().Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promos []models.Promotion
+	if err := cursor.All(ctx, &promos); err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+// ListPromotions mengambil semua promosi dengan paginasi, terbaru lebih dulu
+func ListPromotions(ctx context.Context, page, limit int64) ([]models.Promotion, int64, error) {
+	total, err := promotionsCollection().CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := promotionsCollection().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	promos := []models.Promotion{}
+	if err := cursor.All(ctx, &promos); err != nil {
+		return nil, 0, err
+	}
+	return promos, total, nil
+}
+
+// InsertPromotion menyimpan promosi baru; kode voucher duplikat menghasilkan duplicate key error
+func InsertPromotion(ctx context.Context, promo models.Promotion) error {
+	_, err := promotionsCollection().InsertOne(ctx, promo)
+	return err
+}
+
+// ReplacePromotion mengganti data promosi, used_count dan created_at dipertahankan
+func ReplacePromotion(ctx context.Context, promo models.Promotion) error {
+	result, err := promotionsCollection().UpdateOne(ctx,
+		bson.M{"promotion_id": promo.PromotionID},
+		bson.M{
+			"$set": bson.M{
+				"name":                 promo.Name,
+				"type":                 promo.Type,
+				"scope":                promo.Scope,
+				"percentage":           promo.Percentage,
+				"amount":               promo.Amount,
+				"max_discount":         promo.MaxDiscount,
+				"product_ids":          promo.ProductIDs,
+				"category_ids":         promo.CategoryIDs,
+				"min_spend":            promo.MinSpend,
+				"usage_limit":          promo.UsageLimit,
+				"usage_limit_per_user": promo.UsageLimitPerUser,
+				"starts_at":            promo.StartsAt,
+				"ends_at":              promo.EndsAt,
+				"active":               promo.Active,
+				"modified_at":          promo.ModifiedAt,
+			},
+		})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeletePromotion menghapus promosi
+func DeletePromotion(ctx context.Context, promotionID string) error {
+	result, err := promotionsCollection().DeleteOne(ctx, bson.M{"promotion_id": promotionID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CountPromotionUsageByUser menghitung berapa kali user memakai promosi
+func CountPromotionUsageByUser(ctx context.Context, promotionID, userID string) (int64, error) {
+	return config.GetCollection("promotion_usages").CountDocuments(ctx, bson.M{
+		"promotion_id": promotionID,
+		"user_id":      userID,
+	})
+}
+
+// ReservePromotionUsage menaikkan used_count secara atomik selama batas global belum tercapai.
+// Mengembalikan false jika batas sudah habis.
+func ReservePromotionUsage(ctx context.Context, promotionID string) (bool, error) {
+	result, err := promotionsCollection().UpdateOne(ctx,
+		bson.M{
+			"promotion_id": promotionID,
+			"$or": bson.A{
+				bson.M{"usage_limit": 0},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$used_count", "$usage_limit"}}},
+			},
+		},
+		bson.M{"$inc": bson.M{"used_count": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ReleasePromotionUsage membatalkan reservasi pemakaian promosi
+func ReleasePromotionUsage(ctx context.Context, promotionID string) error {
+	_, err := promotionsCollection().UpdateOne(ctx,
+		bson.M{"promotion_id": promotionID, "used_count": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"used_count": -1}},
+	)
+	return err
+}
+
+// ReserveUserPromotionUsage mencatat pemakaian promosi oleh user dengan mengambil jatah (slot)
+// kosong pertama dari 1 sampai limit. Unique index (promotion_id, user_id, slot) menjamin dua
+// checkout bersamaan tidak bisa memakai jatah yang sama. Mengembalikan false jika semua jatah
+// sudah terpakai; limit 0 berarti tanpa batas per user sehingga usage dicatat tanpa slot.
+// Pemakaian tanpa slot dari sebelum batas per user dipasang ikut menghabiskan jatah, sehingga
+// slot yang bisa diambil dimulai setelah jumlahnya.
+func ReserveUserPromotionUsage(ctx context.Context, usage models.PromotionUsage, limit int) (bool, error) {
+	collection := config.GetCollection("promotion_usages")
+	if limit <= 0 {
+		usage.Slot = 0
+		_, err := collection.InsertOne(ctx, usage)
+		return err == nil, err
+	}
+
+	slotless, err := collection.CountDocuments(ctx, bson.M{
+		"promotion_id": usage.PromotionID,
+		"user_id":      usage.UserID,
+		"slot":         bson.M{"$exists": false},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for slot := int(slotless) + 1; slot <= limit; slot++ {
+		usage.Slot = slot
+		_, err := collection.InsertOne(ctx, usage)
+		if err == nil {
+			return true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// ReleaseCheckoutPromotionUsage menghapus catatan pemakaian promosi milik checkoutID dan
+// menurunkan used_count dalam satu transaksi. used_count hanya diturunkan jika catatannya masih
+// ada sehingga pelepasan aman diulang.
+func ReleaseCheckoutPromotionUsage(ctx context.Context, promotionID, checkoutID string) error {
+	return withTransaction(ctx, func(sc mongo.SessionContext) error {
+		result, err := config.GetCollection("promotion_usages").DeleteOne(sc, bson.M{
+			"promotion_id": promotionID,
+			"checkout_id":  checkoutID,
+		})
+		if err != nil || result.DeletedCount == 0 {
+			return err
+		}
+		return ReleasePromotionUsage(sc, promotionID)
+	})
+}
+
+// DeletePromotionUsageByCheckout menghapus catatan pemakaian promosi dari checkout yang dibatalkan
+// sehingga tidak lagi dihitung dalam batas per user
+func DeletePromotionUsageByCheckout(ctx context.Context, checkoutID string) error {
+	_, err := config.GetCollection("promotion_usages").DeleteMany(ctx, bson.M{"checkout_id": checkoutID})
+	return err
+}
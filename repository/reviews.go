@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"math"
+)
+
+func reviewsCollection() *mongo.Collection {
+	return config.GetCollection("reviews")
+}
+
+// FindReviewByID mengambil ulasan berdasarkan review_id, mongo.ErrNoDocuments jika tidak ada
+func FindReviewByID(ctx context.Context, reviewID string) (models.Review, error) {
+	var review models.Review
+	err := reviewsCollection().FindOne(ctx, bson.M{"review_id": reviewID}).Decode(&review)
+	return review, err
+}
+
+// FindReviewByUser mengambil ulasan user untuk sebuah produk, mongo.ErrNoDocuments jika belum ada
+func FindReviewByUser(ctx context.Context, productID, userID string) (models.Review, error) {
+	var review models.Review
+	err := reviewsCollection().FindOne(ctx, bson.M{"product_id": productID, "user_id": userID}).Decode(&review)
+	return review, err
+}
+
+// InsertReview menyimpan ulasan baru; ulasan kedua untuk produk yang sama menghasilkan duplicate key error
+func InsertReview(ctx context.Context, review models.Review) error {
+	_, err := reviewsCollection().InsertOne(ctx, review)
+	return err
+}
+
+// UpdateReview menerapkan $set pada ulasan dengan review_id tertentu
+func UpdateReview(ctx context.Context, reviewID string, fields bson.M) error {
+	result, err := reviewsCollection().UpdateOne(ctx, bson.M{"review_id": reviewID}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AnonymizeReviewsByUser mengganti nama penulis ulasan milik user dengan AnonymizedUserName.
+// Rating dan komentar tetap disimpan agar rating produk tidak berubah.
+func AnonymizeReviewsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := reviewsCollection().UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"user_name": AnonymizedUserName}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteReview menghapus ulasan berdasarkan review_id
+func DeleteReview(ctx context.Context, reviewID string) error {
+	result, err := reviewsCollection().DeleteOne(ctx, bson.M{"review_id": reviewID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ListReviews mengambil ulasan dengan paginasi dan urutan tertentu beserta total dokumen yang cocok
+func ListReviews(ctx context.Context, filter bson.M, sort bson.D, page, limit int64) ([]models.Review, int64, error) {
+	total, err := reviewsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(sort).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := reviewsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// RefreshProductRating menghitung ulang rata-rata dan jumlah ulasan yang disetujui
+// lalu menyimpannya di dokumen produk agar tidak perlu dihitung setiap kali produk dibaca
+func RefreshProductRating(ctx context.Context, productID string) error {
+	cursor, err := reviewsCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID, "status": models.ReviewApproved}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stats []struct {
+		Average float64 `bson:"average"`
+		Count   int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return err
+	}
+
+	average, count := 0.0, 0
+	if len(stats) > 0 {
+		average = math.Round(stats[0].Average*10) / 10
+		count = stats[0].Count
+	}
+	_, err = productsCollection().UpdateOne(ctx,
+		bson.M{"product_id": productID},
+		bson.M{"$set": bson.M{"rating_average": average, "rating_count": count}},
+	)
+	return err
+}
+
+// HasCompletedPurchase memeriksa apakah user pernah menyelesaikan pembelian produk,
+// baik melalui checkout yang sudah selesai maupun pesanan yang sudah diterima
+func HasCompletedPurchase(ctx context.Context, userID, productID string) (bool, error) {
+	err := config.GetCollection("checkout").FindOne(ctx, bson.M{
+		"user_id":          userID,
+		"items.product_id": productID,
+		"status":           models.CheckoutStatusCompleted,
+	}).Err()
+	if err == nil {
+		return true, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	err = config.GetCollection("orders").FindOne(ctx, bson.M{
+		"user_id":          userID,
+		"items.product_id": productID,
+		"order_status":     bson.M{"$regex": "^(completed|delivered)$", "$options": "i"},
+	}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// withTransaction menjalankan fn di dalam transaksi MongoDB sehingga semua tulisannya tersimpan
+// bersama atau tidak sama sekali. fn bisa dipanggil ulang untuk error sementara, jadi fn tidak
+// boleh punya efek samping di luar database. Error dari fn dikembalikan apa adanya.
+func withTransaction(ctx context.Context, fn func(ctx mongo.SessionContext) error) error {
+	session, err := config.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func usersCollection() *mongo.Collection {
+	return config.GetCollection("users")
+}
+
+// FindUserByEmail mengambil user berdasarkan email, mongo.ErrNoDocuments jika tidak ada
+func FindUserByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := usersCollection().FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	return user, err
+}
+
+// FindUserByID mengambil user berdasarkan userid, mongo.ErrNoDocuments jika tidak ada
+func FindUserByID(ctx context.Context, userID string) (models.User, error) {
+	var user models.User
+	err := usersCollection().FindOne(ctx, bson.M{"userid": userID}).Decode(&user)
+	return user, err
+}
+
+// InsertUser menyimpan user baru; email duplikat menghasilkan duplicate key error
+func InsertUser(ctx context.Context, user models.User) error {
+	_, err := usersCollection().InsertOne(ctx, user)
+	return err
+}
+
+// UpdateUserByEmail menerapkan $set pada user dengan email tertentu
+func UpdateUserByEmail(ctx context.Context, email string, fields bson.M) error {
+	result, err := usersCollection().UpdateOne(ctx, bson.M{"email": email}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateUserByID menerapkan $set pada user dengan userid tertentu
+func UpdateUserByID(ctx context.Context, userID string, fields bson.M) error {
+	result, err := usersCollection().UpdateOne(ctx, bson.M{"userid": userID}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Buku alamat diubah per elemen dengan $push, $pull, dan positional $set agar dua request
+// bersamaan tidak saling menimpa seluruh array. mongo.ErrNoDocuments berarti user atau
+// alamatnya tidak ditemukan, atau batas jumlah alamat sudah tercapai untuk PushUserAddress.
+
+// PushUserAddress menambahkan alamat selama buku alamat user berisi kurang dari max alamat
+func PushUserAddress(ctx context.Context, userID string, address models.SavedAddress, max int) error {
+	result, err := usersCollection().UpdateOne(ctx,
+		bson.M{"userid": userID, fmt.Sprintf("addresses.%d", max-1): bson.M{"$exists": false}},
+		bson.M{"$push": bson.M{"addresses": address}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateUserAddress mengganti isi alamat dengan address_id yang sama; is_default dan created_at
+// tidak diubah
+func UpdateUserAddress(ctx context.Context, userID string, address models.SavedAddress) error {
+	result, err := usersCollection().UpdateOne(ctx,
+		bson.M{"userid": userID, "addresses.address_id": address.AddressID},
+		bson.M{"$set": bson.M{
+			"addresses.$.label":          address.Label,
+			"addresses.$.recipient_name": address.RecipientName,
+			"addresses.$.phone_number":   address.PhoneNumber,
+			"addresses.$.street":         address.Street,
+			"addresses.$.district":       address.District,
+			"addresses.$.city":           address.City,
+			"addresses.$.city_id":        address.CityID,
+			"addresses.$.province":       address.Province,
+			"addresses.$.postal_code":    address.PostalCode,
+			"addresses.$.modified_at":    address.ModifiedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetDefaultUserAddress menjadikan addressID satu-satunya alamat utama dalam satu update
+func SetDefaultUserAddress(ctx context.Context, userID, addressID string) error {
+	result, err := usersCollection().UpdateOne(ctx,
+		bson.M{"userid": userID, "addresses.address_id": addressID},
+		bson.M{"$set": bson.M{
+			"addresses.$[other].is_default":  false,
+			"addresses.$[target].is_default": true,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"other.address_id": bson.M{"$ne": addressID}},
+			bson.M{"target.address_id": addressID},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// PullUserAddress menghapus alamat dengan addressID dari buku alamat user
+func PullUserAddress(ctx context.Context, userID, addressID string) error {
+	result, err := usersCollection().UpdateOne(ctx,
+		bson.M{"userid": userID, "addresses.address_id": addressID},
+		bson.M{"$pull": bson.M{"addresses": bson.M{"address_id": addressID}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// EnsureDefaultUserAddress menjadikan alamat tertua sebagai alamat utama jika buku alamat tidak
+// kosong tetapi belum punya alamat utama, misalnya setelah alamat pertama ditambahkan atau
+// alamat utama dihapus
+func EnsureDefaultUserAddress(ctx context.Context, userID string) error {
+	_, err := usersCollection().UpdateOne(ctx,
+		bson.M{
+			"userid":               userID,
+			"addresses.0":          bson.M{"$exists": true},
+			"addresses.is_default": bson.M{"$ne": true},
+		},
+		bson.M{"$set": bson.M{"addresses.0.is_default": true}},
+	)
+	return err
+}
+
+// ListUsers mengambil user dengan paginasi, terbaru lebih dulu, beserta total dokumen yang cocok
+func ListUsers(ctx context.Context, filter bson.M, page, limit int64) ([]models.User, int64, error) {
+	total, err := usersCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := usersCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// DeleteUserByID menghapus dokumen user
+func DeleteUserByID(ctx context.Context, userID string) error {
+	result, err := usersCollection().DeleteOne(ctx, bson.M{"userid": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindActiveUsersByRole mengambil semua user aktif dengan role tertentu, misalnya penerima notifikasi admin
+func FindActiveUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	cursor, err := usersCollection().Find(ctx, bson.M{"role": role, "disabled": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
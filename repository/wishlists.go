@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+func wishlistsCollection() *mongo.Collection {
+	return config.GetCollection("wishlists")
+}
+
+// AddWishlistItem menyimpan produk ke wishlist user; menyimpan produk yang sama dua kali tidak berpengaruh.
+// created bernilai false jika produk sudah ada di wishlist.
+func AddWishlistItem(ctx context.Context, userID, productID string) (created bool, err error) {
+	result, err := wishlistsCollection().UpdateOne(ctx,
+		bson.M{"user_id": userID, "product_id": productID},
+		bson.M{"$setOnInsert": models.WishlistItem{UserID: userID, ProductID: productID, CreatedAt: time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// RemoveWishlistItem menghapus produk dari wishlist user, mongo.ErrNoDocuments jika tidak ada
+func RemoveWishlistItem(ctx context.Context, userID, productID string) error {
+	result, err := wishlistsCollection().DeleteOne(ctx, bson.M{"user_id": userID, "product_id": productID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ListWishlistItems mengambil wishlist user, terbaru lebih dulu
+func ListWishlistItems(ctx context.Context, userID string) ([]models.WishlistItem, error) {
+	cursor, err := wishlistsCollection().Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.WishlistItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteWishlistByUser menghapus seluruh wishlist milik user
+func DeleteWishlistByUser(ctx context.Context, userID string) error {
+	_, err := wishlistsCollection().DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// CountWishlistByProduct menghitung jumlah user yang menyimpan produk di wishlist
+func CountWishlistByProduct(ctx context.Context, productID string) (int64, error) {
+	return wishlistsCollection().CountDocuments(ctx, bson.M{"product_id": productID})
+}
+
+// WishlistCounts mengembalikan produk yang paling banyak disimpan di wishlist
+func WishlistCounts(ctx context.Context, limit int64) ([]models.WishlistCount, error) {
+	cursor, err := wishlistsCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$product_id", "users": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "users", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []models.WishlistCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// FindWishlistItem mengambil satu item wishlist, mongo.ErrNoDocuments jika tidak ada
+func FindWishlistItem(ctx context.Context, userID, productID string) (models.WishlistItem, error) {
+	var item models.WishlistItem
+	err := wishlistsCollection().FindOne(ctx, bson.M{"user_id": userID, "product_id": productID}).Decode(&item)
+	return item, err
+}
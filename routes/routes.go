@@ -1,56 +1,175 @@
 package routes
 
 import (
+	"be-stepup/audit"
+	"be-stepup/config"
 	"be-stepup/controllers"
 	"be-stepup/middleware"
+	"be-stepup/models"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
 // SetupRoutes mengatur semua rute yang digunakan dalam aplikasi
 func SetupRoutes(app *fiber.App) {
+	// Rute upload file mendapat deadline lebih panjang dari REQUEST_TIMEOUT
+	uploadTimeout := middleware.Timeout(config.ConfigDuration("UPLOAD_TIMEOUT", 30*time.Second))
+
 	// Grup rute untuk produk
 	productGroup := app.Group("/api/products")
-	productGroup.Get("/", controllers.GetAllProducts)             // Mengambil semua produk
+	productGroup.Get("/", controllers.GetAllProducts)             // Mengambil semua produk yang sedang tayang
 	productGroup.Get("/:id", controllers.GetProductByID)          // Mengambil produk berdasarkan ID
 	productGroup.Get("/code/:code", controllers.GetProductByCode) // Mengambil produk berdasarkan kode unik
-	productGroup.Post("/", controllers.CreateProduct)             // Membuat produk baru
-	productGroup.Put("/:id", controllers.UpdateProduct)           // Memperbarui produk berdasarkan ID
-	productGroup.Delete("/:id", controllers.DeleteProduct)        // Menghapus produk berdasarkan ID
 
-	// Rute untuk user
-	app.Get("/api/users", controllers.GetAllUsers)
-	app.Get("/api/users/:id", controllers.GetUserByID)
+	// Perubahan produk khusus admin agar setiap perubahan di riwayat produk memiliki pelaku
+	requireAdmin := middleware.RequireRole(models.RoleAdmin)
+	// Staf menangani pesanan, verifikasi pembayaran, dan moderasi ulasan; katalog, promosi, dan
+	// manajemen user tetap khusus admin
+	requireStaff := middleware.RequireRole(models.RoleAdmin, models.RoleStaff)
+	productGroup.Post("/", middleware.JWTAuthMiddleware, requireAdmin, uploadTimeout, controllers.CreateProduct) // Membuat produk baru
+	productGroup.Put("/:id", middleware.JWTAuthMiddleware, requireAdmin, controllers.UpdateProduct)              // Memperbarui produk berdasarkan ID
+	productGroup.Patch("/:id", middleware.JWTAuthMiddleware, requireAdmin, controllers.PatchProduct)             // Memperbarui sebagian field produk; dukung If-Match
+	productGroup.Delete("/:id", middleware.JWTAuthMiddleware, requireAdmin, controllers.DeleteProduct)           // Menghapus produk (soft delete) berdasarkan ID
+
+	// Ulasan produk; hanya pembeli terverifikasi yang dapat menulis ulasan
+	productGroup.Get("/:product_id/reviews", controllers.ListProductReviews)
+	productGroup.Post("/:product_id/reviews", middleware.JWTAuthMiddleware, uploadTimeout, controllers.CreateReview)
+	productGroup.Put("/:product_id/reviews/me", middleware.JWTAuthMiddleware, uploadTimeout, controllers.UpdateMyReview)
+	productGroup.Delete("/:product_id/reviews/me", middleware.JWTAuthMiddleware, controllers.DeleteMyReview)
+
+	// Kategori dan merek produk beserta jumlah produknya
+	app.Get("/api/categories", controllers.GetAllCategories)
+	app.Get("/api/categories/:id", controllers.GetCategoryByID) // Kategori beserta subkategori langsungnya
+	app.Get("/api/brands", controllers.GetAllBrands)
+	app.Get("/api/brands/:id", controllers.GetBrandByID)
+
+	// Rute untuk user (khusus admin; user biasa memakai /api/me)
+	app.Get("/api/users", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin), controllers.GetAllUsers)
+	app.Get("/api/users/:id", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin), controllers.GetUserByID)
+
+	// Profil user yang sedang login, selalu berdasarkan identitas dari JWT
+	meGroup := app.Group("/api/me", middleware.JWTAuthMiddleware)
+	meGroup.Get("/", controllers.GetMe)
+	meGroup.Put("/", controllers.UpdateMe)
+	meGroup.Put("/password", controllers.ChangeMyPassword)             // Mengganti password (wajib password lama)
+	meGroup.Post("/avatar", uploadTimeout, controllers.UploadMyAvatar) // Mengunggah foto profil
+	meGroup.Delete("/", controllers.DeleteMe)                          // Menghapus akun dan menganonimkan riwayat pesanan
+
+	// Manajemen user khusus admin
+	adminUserGroup := app.Group("/api/admin/users", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	adminUserGroup.Get("/", controllers.AdminListUsers)    // Daftar user dengan paginasi
+	adminUserGroup.Post("/", controllers.AdminCreateStaff) // Membuat akun staff/admin
+
+	// Perubahan role dan penonaktifan akun dicatat di audit
+	adminUserGroup.Put("/:id/role", audit.Middleware(models.AuditUserRole, "user", "id"), controllers.AdminUpdateUserRole)
+	adminUserGroup.Put("/:id/status", audit.Middleware(models.AuditUserStatus, "user", "id"), controllers.AdminUpdateUserStatus)
+
+	// Manajemen promosi dan voucher khusus admin
+	promotionGroup := app.Group("/api/admin/promotions", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	promotionGroup.Get("/", controllers.GetAllPromotions)
+	promotionGroup.Get("/:id", controllers.GetPromotionByID)
+	promotionGroup.Post("/", controllers.CreatePromotion)
+	promotionGroup.Put("/:id", controllers.UpdatePromotion)
+	promotionGroup.Delete("/:id", controllers.DeletePromotion)
+
+	// Buku alamat milik user yang sedang login
+	addressGroup := meGroup.Group("/addresses")
+	addressGroup.Get("/", controllers.GetMyAddresses)
+	addressGroup.Post("/", controllers.CreateMyAddress)
+	addressGroup.Put("/:address_id", controllers.UpdateMyAddress)
+	addressGroup.Put("/:address_id/default", controllers.SetMyDefaultAddress) // Menjadikan alamat utama
+	addressGroup.Delete("/:address_id", controllers.DeleteMyAddress)
+
+	// Wishlist milik user yang sedang login
+	wishlistGroup := meGroup.Group("/wishlist")
+	wishlistGroup.Get("/", controllers.GetMyWishlist)
+	wishlistGroup.Post("/", controllers.AddToWishlist)
+	wishlistGroup.Delete("/:product_id", controllers.RemoveFromWishlist)
+	wishlistGroup.Post("/:product_id/move-to-cart", controllers.MoveWishlistToCart) // Memindahkan produk ke keranjang
+
+	// Statistik wishlist khusus admin
+	adminWishlistGroup := app.Group("/api/admin/wishlists", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	adminWishlistGroup.Get("/", controllers.GetWishlistCounts)                  // Produk yang paling banyak di-wishlist
+	adminWishlistGroup.Get("/:product_id", controllers.GetProductWishlistCount) // Jumlah user yang menyimpan satu produk
+
+	// Moderasi ulasan oleh admin dan staf
+	adminReviewGroup := app.Group("/api/admin/reviews", middleware.JWTAuthMiddleware, requireStaff)
+	adminReviewGroup.Get("/", controllers.AdminListReviews)
+	adminReviewGroup.Put("/:review_id/status", controllers.AdminModerateReview) // Menyetujui atau menyembunyikan ulasan
+
+	// Manajemen kategori dan merek khusus admin
+	adminCategoryGroup := app.Group("/api/admin/categories", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	adminCategoryGroup.Post("/", controllers.CreateCategory)
+	adminCategoryGroup.Put("/:id", controllers.UpdateCategory)
+	adminCategoryGroup.Delete("/:id", controllers.DeleteCategory) // Hanya jika tidak ada subkategori maupun produk
+	adminBrandGroup := app.Group("/api/admin/brands", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	adminBrandGroup.Post("/", controllers.CreateBrand)
+	adminBrandGroup.Put("/:id", controllers.UpdateBrand)
+	adminBrandGroup.Delete("/:id", controllers.DeleteBrand) // Hanya jika tidak digunakan oleh produk
+
+	// Impor dan ekspor katalog produk khusus admin
+	adminProductGroup := app.Group("/api/admin/products", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	adminProductGroup.Post("/import", uploadTimeout, controllers.AdminImportProducts) // Impor CSV/XLSX di latar belakang, ?dry_run=true untuk validasi
+	adminProductGroup.Get("/import/:job_id", controllers.AdminGetImportJob)           // Progres dan laporan kesalahan impor
+	adminProductGroup.Get("/export", controllers.AdminExportProducts)                 // Ekspor katalog, ?format=csv|xlsx
+	adminProductGroup.Get("/audit", controllers.AdminListProductAudits)               // Riwayat perubahan semua produk dengan filter
+	adminProductGroup.Get("/", controllers.AdminListProducts)                         // Semua produk termasuk draft, arsip, dan terhapus
+	adminProductGroup.Get("/:id", controllers.AdminGetProduct)
+	adminProductGroup.Get("/:id/audit", controllers.AdminGetProductAudits)     // Riwayat perubahan satu produk
+	adminProductGroup.Put("/:id/status", controllers.AdminUpdateProductStatus) // Status dan jadwal tayang produk
+	adminProductGroup.Post("/:id/restore", controllers.AdminRestoreProduct)    // Memulihkan produk yang dihapus
+
+	// Ledger inventaris khusus admin
+	inventoryGroup := app.Group("/api/admin/inventory", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin))
+	inventoryGroup.Get("/movements", controllers.AdminListInventoryMovements)
+	inventoryGroup.Post("/adjustments", controllers.AdminAdjustInventory)   // Barang masuk, retur, atau koreksi stok
+	inventoryGroup.Get("/low-stock", controllers.AdminListLowStockProducts) // Produk di bawah batas stok, urut kecepatan penjualan
+	inventoryGroup.Get("/alerts", controllers.AdminListLowStockAlerts)      // Riwayat peringatan stok menipis
+
+	// Event audit tindakan sensitif khusus admin
+	app.Get("/api/admin/audit", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin), controllers.AdminListAuditEvents)
+
+	// Status job latar belakang khusus admin
+	app.Get("/api/admin/jobs", middleware.JWTAuthMiddleware, middleware.RequireRole(models.RoleAdmin), controllers.GetJobStatus)
 
 	// Rute untuk mengunggah gambar
-	app.Post("/api/upload", controllers.UploadImage) // Mengunggah gambar produk
+	app.Post("/api/upload", uploadTimeout, controllers.UploadImage) // Mengunggah gambar produk
 
 	// Grup rute untuk autentikasi
 	authGroup := app.Group("/api/auth")
 	authGroup.Post("/login", controllers.Login)       // Login user/admin
-	authGroup.Post("/register", controllers.Register) // Registrasi user/admin
+	authGroup.Post("/register", controllers.Register) // Registrasi user (role selalu "user")
 
 	// Rute untuk menghitung jumlah produk dan pengguna
 	app.Get("/api/count/products", controllers.GetProductCount)
 	app.Get("/api/count/users", controllers.GetUserCount)
 
-	// Rute keranjang belanja (dengan autentikasi)
-	app.Post("/api/cart/add", middleware.JWTAuthMiddleware, controllers.AddToCart)                        // Menambahkan item ke keranjang
-	app.Delete("/api/cart/remove-single", middleware.JWTAuthMiddleware, controllers.RemoveSingleCartItem) // Menghapus item dari keranjang
-	app.Get("/api/cart", middleware.JWTAuthMiddleware, controllers.GetAllCart)                            // Mendapatkan semua item dalam keranjang
-	app.Put("/api/cart", middleware.JWTAuthMiddleware, controllers.UpdateCartItem)
+	// Rute keranjang belanja; tamu memakai token keranjang, user login memakai JWT
+	app.Post("/api/cart/add", middleware.CartIdentity, controllers.AddToCart)                        // Menambahkan item ke keranjang
+	app.Delete("/api/cart/remove-single", middleware.CartIdentity, controllers.RemoveSingleCartItem) // Menghapus item dari keranjang
+	app.Get("/api/cart", middleware.CartIdentity, controllers.GetAllCart)                            // Mendapatkan semua item dalam keranjang
+	app.Put("/api/cart", middleware.CartIdentity, controllers.UpdateCartItem)
+	app.Post("/api/cart/preview", middleware.JWTAuthMiddleware, controllers.PreviewCart) // Pratinjau total dengan promosi/voucher
+
+	// Rute ongkos kirim (dengan autentikasi)
+	app.Post("/api/shipping/rates", middleware.JWTAuthMiddleware, controllers.GetShippingRates) // Pilihan kurir dan ongkos kirim
 
 	// Rute checkout (dengan autentikasi)
 	app.Post("/api/checkout", middleware.JWTAuthMiddleware, controllers.CreateCheckout)              // Membuat checkout baru
 	app.Get("/api/checkout/:checkout_id", middleware.JWTAuthMiddleware, controllers.GetCheckoutByID) // Mendapatkan checkout berdasarkan ID
-	app.Put("/api/checkout/:checkout_id", controllers.UpdateCheckout)                                // Memperbarui checkout berdasarkan ID
-	app.Get("/checkouts", controllers.GetAllCheckout)
-	app.Delete("/checkout/:checkout_id", controllers.DeleteCheckout)
+
+	// Memperbarui status checkout berdasarkan ID (admin dan staf); audit dipasang setelah autentikasi
+	// agar request anonim tidak memenuhi audit_events
+	app.Put("/api/checkout/:checkout_id", middleware.JWTAuthMiddleware, requireStaff, audit.Middleware(models.AuditCheckoutStatus, "checkout", "checkout_id"), controllers.UpdateCheckout)
+	app.Get("/checkouts", middleware.JWTAuthMiddleware, requireStaff, controllers.GetAllCheckout)                // Berisi alamat dan nomor telepon pembeli
+	app.Delete("/checkout/:checkout_id", middleware.JWTAuthMiddleware, requireAdmin, controllers.DeleteCheckout) // Hanya checkout yang sudah selesai atau dibatalkan
 
 	// Rute pembayaran (dengan autentikasi)
 	paymentGroup := app.Group("/api/payment", middleware.JWTAuthMiddleware)
-	paymentGroup.Post("/:checkout_id", controllers.SavePayment)           // Menyimpan bukti pembayaran
-	paymentGroup.Get("/:checkout_id", controllers.GetPaymentByCheckoutID) // Mendapatkan bukti pembayaran berdasarkan checkoutID
+	paymentGroup.Post("/:checkout_id", uploadTimeout, controllers.SavePayment) // Menyimpan bukti pembayaran
+	paymentGroup.Get("/:checkout_id", controllers.GetPaymentByCheckoutID)      // Mendapatkan bukti pembayaran berdasarkan checkoutID
 
-	app.Put("/:payment_id/status", controllers.UpdatePaymentStatus) // Memperbarui status pembayaran
-	app.Get("/payments", controllers.GetAllPayments)
+	// Memperbarui status pembayaran (admin dan staf); audit dipasang setelah autentikasi seperti di atas
+	app.Put("/:payment_id/status", middleware.JWTAuthMiddleware, requireStaff, audit.Middleware(models.AuditPaymentStatus, "payment", "payment_id"), controllers.UpdatePaymentStatus)
+	app.Get("/payments", middleware.JWTAuthMiddleware, requireStaff, controllers.GetAllPayments)
 }
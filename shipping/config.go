@@ -0,0 +1,39 @@
+package shipping
+
+import (
+	"be-stepup/config"
+	"be-stepup/models"
+	"strings"
+)
+
+// NewProviderFromConfig memilih RateProvider dari SHIPPING_PROVIDER ("table" atau "rajaongkir")
+func NewProviderFromConfig() RateProvider {
+	if config.Config("SHIPPING_PROVIDER") == "rajaongkir" {
+		couriers := strings.Split(config.Config("RAJAONGKIR_COURIERS"), ",")
+		if couriers[0] == "" {
+			couriers = []string{"jne", "pos", "tiki"}
+		}
+		return NewRajaOngkirProvider(
+			config.Config("RAJAONGKIR_BASE_URL"),
+			config.Config("RAJAONGKIR_API_KEY"),
+			couriers,
+		)
+	}
+	return NewTableProvider()
+}
+
+// OriginFromConfig mengembalikan alamat gudang asal pengiriman
+func OriginFromConfig() models.Address {
+	origin := models.Address{
+		City:     config.Config("SHIPPING_ORIGIN_CITY"),
+		CityID:   config.Config("SHIPPING_ORIGIN_CITY_ID"),
+		Province: config.Config("SHIPPING_ORIGIN_PROVINCE"),
+	}
+	if origin.City == "" {
+		origin.City = "Jakarta Selatan"
+	}
+	if origin.Province == "" {
+		origin.Province = "DKI Jakarta"
+	}
+	return origin
+}
@@ -0,0 +1,126 @@
+package shipping
+
+import (
+	"be-stepup/package/money"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RajaOngkirProvider memanggil endpoint /cost bergaya RajaOngkir.
+// BaseURL dan HTTPClient bisa diarahkan ke server palsu lokal untuk pengujian.
+type RajaOngkirProvider struct {
+	BaseURL    string   // Misalnya https://api.rajaongkir.com/starter
+	APIKey     string   // Dikirim sebagai header "key"
+	Couriers   []string // Kurir yang ditanyakan jika RateRequest.Courier kosong
+	HTTPClient *http.Client
+}
+
+// NewRajaOngkirProvider membuat provider dengan timeout HTTP bawaan
+func NewRajaOngkirProvider(baseURL, apiKey string, couriers []string) *RajaOngkirProvider {
+	return &RajaOngkirProvider{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     apiKey,
+		Couriers:   couriers,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type rajaOngkirResponse struct {
+	RajaOngkir struct {
+		Status struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"status"`
+		Results []struct {
+			Code  string `json:"code"`
+			Costs []struct {
+				Service     string `json:"service"`
+				Description string `json:"description"`
+				Cost        []struct {
+					Value int64  `json:"value"`
+					ETD   string `json:"etd"`
+				} `json:"cost"`
+			} `json:"costs"`
+		} `json:"results"`
+	} `json:"rajaongkir"`
+}
+
+// Rates mengimplementasikan RateProvider
+func (p *RajaOngkirProvider) Rates(ctx context.Context, req RateRequest) ([]Rate, error) {
+	if req.Origin.CityID == "" || req.Destination.CityID == "" {
+		return nil, errors.New("rajaongkir: origin and destination city_id are required")
+	}
+
+	couriers := p.Couriers
+	if req.Courier != "" {
+		couriers = []string{strings.ToLower(req.Courier)}
+	}
+
+	var rates []Rate
+	for _, courier := range couriers {
+		courierRates, err := p.rates(ctx, req, courier)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, courierRates...)
+	}
+	return rates, nil
+}
+
+func (p *RajaOngkirProvider) rates(ctx context.Context, req RateRequest, courier string) ([]Rate, error) {
+	form := url.Values{
+		"origin":      {req.Origin.CityID},
+		"destination": {req.Destination.CityID},
+		"weight":      {strconv.Itoa(req.WeightGrams)},
+		"courier":     {courier},
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/cost", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("key", p.APIKey)
+
+	resp, err := p.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("rajaongkir: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body rajaOngkirResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("rajaongkir: invalid response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if status := body.RajaOngkir.Status; status.Code != http.StatusOK {
+		return nil, fmt.Errorf("rajaongkir: %d %s", status.Code, status.Description)
+	}
+	// Proxy atau gateway bisa mengembalikan isi lama dengan status HTTP gagal
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rajaongkir: HTTP %d", resp.StatusCode)
+	}
+
+	var rates []Rate
+	for _, result := range body.RajaOngkir.Results {
+		for _, cost := range result.Costs {
+			if len(cost.Cost) == 0 {
+				continue
+			}
+			rates = append(rates, Rate{
+				Courier:     result.Code,
+				Service:     cost.Service,
+				Description: cost.Description,
+				Fee:         money.IDR(cost.Cost[0].Value),
+				ETD:         cost.Cost[0].ETD,
+			})
+		}
+	}
+	return rates, nil
+}
@@ -0,0 +1,217 @@
+package shipping
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var rajaOngkirRequest = RateRequest{
+	Origin:      models.Address{City: "Jakarta Selatan", CityID: "153"},
+	Destination: models.Address{City: "Bandung", CityID: "23"},
+	WeightGrams: 1700,
+}
+
+const rajaOngkirJNE = `{"rajaongkir":{"status":{"code":200,"description":"OK"},"results":[{"code":"jne","costs":[
+	{"service":"REG","description":"Layanan Reguler","cost":[{"value":18000,"etd":"2-3"}]},
+	{"service":"OKE","description":"Ongkos Kirim Ekonomis","cost":[]},
+	{"service":"YES","description":"Yakin Esok Sampai","cost":[{"value":30000,"etd":"1-1"}]}
+]}]}}`
+
+func TestRajaOngkirRates(t *testing.T) {
+	var couriers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/starter/cost" {
+			t.Errorf("request = %s %s, want POST /starter/cost", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("key"); got != "secret" {
+			t.Errorf("key header = %q, want secret", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("origin") != "153" || r.PostForm.Get("destination") != "23" || r.PostForm.Get("weight") != "1700" {
+			t.Errorf("form = %v", r.PostForm)
+		}
+		courier := r.PostForm.Get("courier")
+		couriers = append(couriers, courier)
+		if courier == "jne" {
+			w.Write([]byte(rajaOngkirJNE))
+			return
+		}
+		w.Write([]byte(`{"rajaongkir":{"status":{"code":200,"description":"OK"},"results":[{"code":"` + courier + `","costs":[]}]}}`))
+	}))
+	defer server.Close()
+
+	provider := NewRajaOngkirProvider(server.URL+"/starter/", "secret", []string{"jne", "pos"})
+	rates, err := provider.Rates(context.Background(), rajaOngkirRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(couriers, []string{"jne", "pos"}) {
+		t.Fatalf("couriers requested = %q, want jne, pos", couriers)
+	}
+	want := []Rate{
+		{Courier: "jne", Service: "REG", Description: "Layanan Reguler", Fee: money.IDR(18000), ETD: "2-3"},
+		{Courier: "jne", Service: "YES", Description: "Yakin Esok Sampai", Fee: money.IDR(30000), ETD: "1-1"},
+	}
+	if !reflect.DeepEqual(rates, want) {
+		t.Fatalf("rates = %+v, want %+v", rates, want)
+	}
+
+	// Kurir pada permintaan menggantikan daftar bawaan
+	couriers = nil
+	req := rajaOngkirRequest
+	req.Courier = "JNE"
+	rate, err := Quote(context.Background(), provider, req, "yes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.Fee != money.IDR(30000) || !reflect.DeepEqual(couriers, []string{"jne"}) {
+		t.Fatalf("rate = %+v, couriers = %q", rate, couriers)
+	}
+	if _, err := Quote(context.Background(), provider, req, "OKE"); err != ErrServiceNotFound {
+		t.Fatalf("err = %v, want ErrServiceNotFound", err)
+	}
+}
+
+func TestRajaOngkirErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"api error", http.StatusBadRequest, `{"rajaongkir":{"status":{"code":400,"description":"Invalid key"}}}`, "rajaongkir: 400 Invalid key"},
+		{"server error", http.StatusBadGateway, `<html>Bad Gateway</html>`, "rajaongkir: invalid response (HTTP 502)"},
+		{"non-200 with ok body", http.StatusServiceUnavailable, rajaOngkirJNE, "rajaongkir: HTTP 503"},
+		{"empty body", http.StatusOK, ``, "rajaongkir: invalid response (HTTP 200)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewRajaOngkirProvider(server.URL, "secret", []string{"jne"})
+			rates, err := provider.Rates(context.Background(), rajaOngkirRequest)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("rates = %+v, err = %v, want %q", rates, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRajaOngkirUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	provider := NewRajaOngkirProvider(server.URL, "secret", []string{"jne"})
+	if _, err := provider.Rates(context.Background(), rajaOngkirRequest); err == nil || !strings.HasPrefix(err.Error(), "rajaongkir: ") {
+		t.Fatalf("err = %v, want rajaongkir transport error", err)
+	}
+}
+
+func TestRajaOngkirRequiresCityID(t *testing.T) {
+	provider := NewRajaOngkirProvider("http://127.0.0.1:0", "secret", []string{"jne"})
+	req := rajaOngkirRequest
+	req.Destination.CityID = ""
+	if _, err := provider.Rates(context.Background(), req); err == nil {
+		t.Fatal("err = nil, want missing city_id error")
+	}
+}
+
+func TestTableProviderZones(t *testing.T) {
+	origin := models.Address{City: "Jakarta Selatan", Province: "DKI Jakarta"}
+	tests := []struct {
+		name        string
+		destination models.Address
+		wantFee     money.Money
+		wantETD     string
+	}{
+		{"same city", models.Address{City: " jakarta selatan ", Province: "dki jakarta"}, money.IDR(10000), "1-2"},
+		{"same province", models.Address{City: "Jakarta Barat", Province: "DKI Jakarta"}, money.IDR(15000), "2-3"},
+		{"same city name other province", models.Address{City: "Jakarta Selatan", Province: "Jawa Barat"}, money.IDR(25000), "3-5"},
+		{"national", models.Address{City: "Bandung", Province: "Jawa Barat"}, money.IDR(25000), "3-5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := Quote(context.Background(), NewTableProvider(), RateRequest{
+				Origin:      origin,
+				Destination: tt.destination,
+				WeightGrams: 1000,
+				Courier:     "jne",
+			}, "REG")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rate.Fee != tt.wantFee || rate.ETD != tt.wantETD {
+				t.Fatalf("rate = %+v, want fee %v etd %s", rate, tt.wantFee, tt.wantETD)
+			}
+		})
+	}
+}
+
+func TestTableProviderWeight(t *testing.T) {
+	address := models.Address{City: "Bandung", Province: "Jawa Barat"}
+	tests := []struct {
+		weightGrams int
+		want        money.Money
+	}{
+		{0, money.IDR(10000)},
+		{1, money.IDR(10000)},
+		{1000, money.IDR(10000)},
+		{1001, money.IDR(18000)},
+		{2000, money.IDR(18000)},
+		{2500, money.IDR(26000)},
+	}
+	for _, tt := range tests {
+		rate, err := Quote(context.Background(), NewTableProvider(), RateRequest{
+			Origin:      address,
+			Destination: address,
+			WeightGrams: tt.weightGrams,
+			Courier:     "JNE",
+		}, "reg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate.Fee != tt.want {
+			t.Errorf("weight %d g: fee = %v, want %v", tt.weightGrams, rate.Fee, tt.want)
+		}
+	}
+}
+
+func TestTableProviderCourierFilter(t *testing.T) {
+	req := RateRequest{WeightGrams: 500}
+	rates, err := NewTableProvider().Rates(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != len(DefaultTable) {
+		t.Fatalf("len(rates) = %d, want %d", len(rates), len(DefaultTable))
+	}
+
+	req.Courier = "pos"
+	rates, err = NewTableProvider().Rates(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Service != "Kilat Khusus" {
+		t.Fatalf("rates = %+v, want only pos Kilat Khusus", rates)
+	}
+
+	req.Courier = "sicepat"
+	if _, err := Quote(context.Background(), NewTableProvider(), req, "REG"); err != ErrServiceNotFound {
+		t.Fatalf("err = %v, want ErrServiceNotFound", err)
+	}
+}
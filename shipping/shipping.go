@@ -0,0 +1,62 @@
+// Package shipping menghitung ongkos kirim dari alamat asal ke alamat tujuan.
+// Implementasi RateProvider bisa berupa tabel zona/berat lokal atau API
+// bergaya RajaOngkir; pemilihannya diatur lewat konfigurasi.
+package shipping
+
+import (
+	"be-stepup/models"
+	"be-stepup/package/money"
+	"context"
+	"errors"
+	"strings"
+)
+
+// DefaultItemWeightGrams dipakai untuk produk yang belum memiliki berat (satu kotak sepatu)
+const DefaultItemWeightGrams = 1000
+
+// ErrServiceNotFound dikembalikan jika kurir/layanan yang dipilih tidak tersedia untuk rute tersebut
+var ErrServiceNotFound = errors.New("layanan pengiriman tidak tersedia untuk alamat ini")
+
+// RateRequest adalah parameter permintaan ongkos kirim
+type RateRequest struct {
+	Origin      models.Address
+	Destination models.Address
+	WeightGrams int
+	Courier     string // Kosong berarti semua kurir yang didukung
+}
+
+// Rate adalah satu pilihan layanan pengiriman
+type Rate struct {
+	Courier     string      `json:"courier"`
+	Service     string      `json:"service"`
+	Description string      `json:"description"`
+	Fee         money.Money `json:"fee"`
+	ETD         string      `json:"etd"` // Estimasi lama pengiriman dalam hari, misalnya "2-3"
+}
+
+// RateProvider menghitung pilihan ongkos kirim
+type RateProvider interface {
+	Rates(ctx context.Context, req RateRequest) ([]Rate, error)
+}
+
+// Quote mencari tarif untuk kurir dan layanan tertentu
+func Quote(ctx context.Context, provider RateProvider, req RateRequest, service string) (Rate, error) {
+	rates, err := provider.Rates(ctx, req)
+	if err != nil {
+		return Rate{}, err
+	}
+	for _, rate := range rates {
+		if strings.EqualFold(rate.Courier, req.Courier) && strings.EqualFold(rate.Service, service) {
+			return rate, nil
+		}
+	}
+	return Rate{}, ErrServiceNotFound
+}
+
+// ItemWeight mengembalikan berat produk, atau berat bawaan jika belum diisi
+func ItemWeight(product models.Product) int {
+	if product.WeightGrams > 0 {
+		return product.WeightGrams
+	}
+	return DefaultItemWeightGrams
+}